@@ -4,6 +4,10 @@ package graph
 import (
 	"graphpass/graph"
 	"graphpass/graph/resolver"
+	"graphpass/password"
+	"graphpass/policy"
+	"graphpass/utils"
+	"strings"
 	"testing"
 
 	"github.com/99designs/gqlgen/client"
@@ -12,15 +16,50 @@ import (
 )
 
 // API response type
+type RuleResult struct {
+	Rule     string
+	Required int
+	Actual   int
+	Passed   bool
+	Message  string
+	Detail   *string
+}
+
 type VerifyResult struct {
-	Verify  bool
-	NoMatch []string
+	Verify        bool
+	NoMatch       []string
+	Results       []RuleResult
+	Score         int
+	Entropy       float64
+	Policy        *string
+	PolicyVersion *int
 }
 
 type QueryResponse struct {
 	Verify VerifyResult
 }
 
+// token that authorizes the policy mutations in the tests
+const adminToken = "s3cr3t-admin-token"
+
+// creates a client of a server with the given store of policies, which sends the admin token
+func newAdminClient(store policy.Store) *client.Client {
+	server := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{Store: store, AdminToken: adminToken}}))
+	return client.New(server, client.AddHeader("Authorization", "Bearer "+adminToken))
+}
+
+// restores, when the test ends, the rule registered under the given name before the test replaced it
+func restoreRule(t *testing.T, name string) {
+	previous, ok := password.Lookup(name)
+	t.Cleanup(func() {
+		if ok {
+			password.Register(name, previous)
+		} else {
+			password.Unregister(name)
+		}
+	})
+}
+
 // TEST CASE 01: Query with password and rule valid
 func TestQueryWithInvalidPasswordAndRule(t *testing.T) {
 	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
@@ -29,10 +68,10 @@ func TestQueryWithInvalidPasswordAndRule(t *testing.T) {
 		verify(
 		  password: "TesteSenhaFortee!123&"
 		  rules: [
-			{rule: "minSize", value: 8},
-			{rule: "minSpecialChars", value: 2},
-			{rule: "noRepeted", value: 0},
-			{rule: "minDigit", value: 4}
+			{rule: minSize, value: 8},
+			{rule: minSpecialChars, value: 2},
+			{rule: noRepeted, value: 0},
+			{rule: minDigit, value: 4}
 		  ]
 		) {
 		  verify
@@ -59,10 +98,10 @@ func TestQueryWithInvalidPassword(t *testing.T) {
 		verify(
 		  password: "TesteSenhaComum4"
 		  rules: [
-			{rule: "minSize", value: 4},
-			{rule: "minSpecialChars", value: 0},
-			{rule: "noRepeted", value: 0},
-			{rule: "minDigit", value: 1}
+			{rule: minSize, value: 4},
+			{rule: minSpecialChars, value: 0},
+			{rule: noRepeted, value: 0},
+			{rule: minDigit, value: 1}
 		]
 		) {
 		  verify
@@ -84,7 +123,7 @@ func TestQueryWithInvalidRule(t *testing.T) {
 	query := `{
 		verify(
 		  password: "TesteSenhaForte123&"
-		  rules: [{rule: "ruleInvalida", value: 8}]
+		  rules: [{rule: ruleInvalida, value: 8}]
 		) {
 		  verify
 		  noMatch
@@ -105,7 +144,7 @@ func TestQueryWithInvalidValue(t *testing.T) {
 	query := `{
 		verify(
 		  password: "TesteSenhaForte123&"
-		  rules: [{rule: "ruleInvalida", value: -1}]
+		  rules: [{rule: minSize, value: -1}]
 		) {
 		  verify
 		  noMatch
@@ -118,3 +157,661 @@ func TestQueryWithInvalidValue(t *testing.T) {
 		c.MustPost(query, &resp)
 	})
 }
+
+// TEST CASE 05: Query with a malformed rule (missing value), rejected by the schema validation
+func TestQueryWithMissingValue(t *testing.T) {
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	query := `{
+		verify(
+		  password: "TesteSenhaForte123&"
+		  rules: [{rule: minSize}]
+		) {
+		  verify
+		  noMatch
+		}
+	  }
+	`
+	var resp interface{}
+	err := c.Post(query, &resp)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "value")
+}
+
+// TEST CASE 06: Query asking for the per-rule results
+func TestQueryWithRuleResults(t *testing.T) {
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	query := `{
+		verify(
+		  password: "Senha1"
+		  rules: [
+			{rule: minSize, value: 4},
+			{rule: minDigit, value: 4}
+		  ]
+		) {
+		  verify
+		  results { rule required actual passed message }
+		}
+	  }
+	`
+	var resp QueryResponse
+	c.MustPost(query, &resp)
+
+	require.False(t, resp.Verify.Verify)
+	require.Equal(t, []RuleResult{
+		{Rule: "minSize", Required: 4, Actual: 6, Passed: true, Message: "the password has 6 characters, at least 4 required"},
+		{Rule: "minDigit", Required: 4, Actual: 1, Passed: false, Message: "the password has 1 digits, at least 4 required"},
+	}, resp.Verify.Results)
+}
+
+// TEST CASE 07: Query asking for the strength of a password that satisfies all the rules
+func TestQueryWithStrength(t *testing.T) {
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	query := `{
+		verify(
+		  password: "Abcd1234!"
+		  rules: [{rule: minSize, value: 8}]
+		) {
+		  verify
+		  score
+		  entropy
+		}
+	  }
+	`
+	var resp QueryResponse
+	c.MustPost(query, &resp)
+
+	require.True(t, resp.Verify.Verify)
+	require.Equal(t, 1, resp.Verify.Score)
+	require.InDelta(t, 31.28, resp.Verify.Entropy, 0.01)
+}
+
+// TEST CASE 08: Query with a non-ASCII password in Unicode mode
+func TestQueryWithUnicodeMode(t *testing.T) {
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	query := `{
+		verify(
+		  password: "パスワード"
+		  rules: [{rule: minSize, value: 6}]
+		  unicode: true
+		) {
+		  verify
+		  results { rule required actual passed message }
+		}
+	  }
+	`
+	var resp QueryResponse
+	c.MustPost(query, &resp)
+
+	require.False(t, resp.Verify.Verify)
+	require.Equal(t, 5, resp.Verify.Results[0].Actual)
+}
+
+// TEST CASE 09: Query with a custom set of special characters
+func TestQueryWithSpecialCharsParam(t *testing.T) {
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	query := `{
+		verify(
+		  password: "minha_senha.segura"
+		  rules: [
+			{rule: minSpecialChars, value: 2},
+			{rule: minSpecialChars, value: 2, param: "_."},
+			{rule: minSpecialChars, value: 2, param: "owasp"}
+		  ]
+		) {
+		  verify
+		  noMatch
+		}
+	  }
+	`
+	var resp QueryResponse
+	c.MustPost(query, &resp)
+
+	require.False(t, resp.Verify.Verify)
+	require.Equal(t, []string{"minSpecialChars"}, resp.Verify.NoMatch)
+}
+
+// TEST CASE 10: Query with a maximum run length of repeated characters
+func TestQueryWithNoRepetedRunLength(t *testing.T) {
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	query := `{
+		verify(
+		  password: "bookkeeper"
+		  rules: [{rule: noRepeted, value: 2}]
+		) {
+		  verify
+		  noMatch
+		}
+	  }
+	`
+	var resp QueryResponse
+	c.MustPost(query, &resp)
+
+	require.True(t, resp.Verify.Verify)
+	require.Empty(t, resp.Verify.NoMatch)
+}
+
+// TEST CASE 11: Query with a password made of sequences of consecutive characters
+func TestQueryWithSequentialChars(t *testing.T) {
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	query := `{
+		verify(
+		  password: "Abcd1234!"
+		  rules: [
+			{rule: minSize, value: 8},
+			{rule: noSequential, value: 3}
+		  ]
+		) {
+		  verify
+		  noMatch
+		}
+	  }
+	`
+	var resp QueryResponse
+	c.MustPost(query, &resp)
+
+	require.False(t, resp.Verify.Verify)
+	require.Equal(t, []string{"noSequential"}, resp.Verify.NoMatch)
+}
+
+// TEST CASE 12: Query with a keyboard pattern in the password
+func TestQueryWithKeyboardPattern(t *testing.T) {
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	query := `{
+		verify(
+		  password: "Qwerty2023!"
+		  rules: [
+			{rule: minSize, value: 8},
+			{rule: noKeyboardPattern, value: 4}
+		  ]
+		) {
+		  verify
+		  noMatch
+		}
+	  }
+	`
+	var resp QueryResponse
+	c.MustPost(query, &resp)
+
+	require.False(t, resp.Verify.Verify)
+	require.Equal(t, []string{"noKeyboardPattern"}, resp.Verify.NoMatch)
+}
+
+// TEST CASE 13: Query with a common password, when a blocklist is configured
+func TestQueryWithCommonPassword(t *testing.T) {
+	restoreRule(t, "notCommon")
+	password.Register("notCommon", password.NotCommon(password.NewBlocklist([]string{"password1", "qwerty123"})))
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	query := `{
+		verify(
+		  password: "Password1"
+		  rules: [
+			{rule: minSize, value: 8},
+			{rule: notCommon, value: 0}
+		  ]
+		) {
+		  verify
+		  noMatch
+		}
+	  }
+	`
+	var resp QueryResponse
+	c.MustPost(query, &resp)
+
+	require.False(t, resp.Verify.Verify)
+	require.Equal(t, []string{"notCommon"}, resp.Verify.NoMatch)
+}
+
+// TEST CASE 14: Query with a variation of a common password, when the normalization is enabled
+func TestQueryWithNormalizedCommonPassword(t *testing.T) {
+	restoreRule(t, "notCommon")
+	password.Register("notCommon", password.NotCommon(password.NewBlocklist([]string{"password"})))
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	query := `{
+		verify(
+		  password: "P@ssw0rd2023!"
+		  rules: [
+			{rule: notCommon, value: 0, param: "normalize"}
+		  ]
+		) {
+		  verify
+		  results { rule required actual passed message detail }
+		}
+	  }
+	`
+	var resp QueryResponse
+	c.MustPost(query, &resp)
+
+	detail := "password"
+	require.False(t, resp.Verify.Verify)
+	require.Equal(t, []RuleResult{
+		{Rule: "notCommon", Actual: 1, Passed: false, Message: "the password is a variation of the common password 'password'", Detail: &detail},
+	}, resp.Verify.Results)
+}
+
+// TEST CASE 15: Query with information about the user in the password
+func TestQueryWithUserInfo(t *testing.T) {
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	query := `{
+		verify(
+		  password: "JohnDoe2023!"
+		  rules: [
+			{rule: minSize, value: 8},
+			{rule: noUserInfo, value: 0}
+		  ]
+		  context: {email: "john.doe@acme.com", companyName: "Acme"}
+		) {
+		  verify
+		  noMatch
+		  results { rule required actual passed message detail }
+		}
+	  }
+	`
+	var resp QueryResponse
+	c.MustPost(query, &resp)
+
+	detail := "john"
+	require.False(t, resp.Verify.Verify)
+	require.Equal(t, []string{"noUserInfo"}, resp.Verify.NoMatch)
+	require.Equal(t, RuleResult{
+		Rule: "noUserInfo", Actual: 4, Passed: false, Message: "the password contains the email of the user", Detail: &detail,
+	}, resp.Verify.Results[1])
+}
+
+// TEST CASE 16: Query with a password too similar to a previous password
+func TestQueryWithPreviousPasswords(t *testing.T) {
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	query := `{
+		verify(
+		  password: "Summer2025!"
+		  rules: [
+			{rule: minSize, value: 8},
+			{rule: minEditDistance, value: 3}
+		  ]
+		  previousPasswords: ["Winter2023!", "Summer2024!"]
+		) {
+		  verify
+		  noMatch
+		}
+	  }
+	`
+	var resp QueryResponse
+	c.MustPost(query, &resp)
+
+	require.False(t, resp.Verify.Verify)
+	require.Equal(t, []string{"minEditDistance"}, resp.Verify.NoMatch)
+
+	// more previous passwords than accepted
+	previous := strings.Repeat(`"Summer2024!", `, password.MaxPreviousPasswords+1)
+	query = `{ verify(password: "Summer2025!", rules: [{rule: minEditDistance, value: 3}], previousPasswords: [` + previous + `]) { verify } }`
+	err := c.Post(query, &resp)
+	require.ErrorContains(t, err, "at most 20 previous passwords are accepted")
+}
+
+// TEST CASE 17: Query with characters outside of the allowed set
+func TestQueryWithAllowedCharset(t *testing.T) {
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	query := `{
+		verify(
+		  password: "Senha ção!"
+		  rules: [
+			{rule: allowedCharset, value: 0, param: "\\p{Latin}0-9!"},
+			{rule: forbiddenChars, value: 0, param: "\\p{White_Space}\"'"}
+		  ]
+		) {
+		  verify
+		  results { rule required actual passed message detail }
+		}
+	  }
+	`
+	var resp QueryResponse
+	c.MustPost(query, &resp)
+
+	detail := " "
+	require.False(t, resp.Verify.Verify)
+	require.Equal(t, []RuleResult{
+		{Rule: "allowedCharset", Actual: 1, Passed: false, Message: "the password has 1 characters outside of the allowed set", Detail: &detail},
+		{Rule: "forbiddenChars", Actual: 1, Passed: false, Message: "the password has 1 forbidden characters", Detail: &detail},
+	}, resp.Verify.Results)
+}
+
+// TEST CASE 18: Query with regular expressions supplied by the user
+func TestQueryWithRegex(t *testing.T) {
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	query := `{
+		verify(
+		  password: "Summer2024!"
+		  rules: [
+			{rule: matchesRegex, value: 0, param: "^[A-Za-z]"},
+			{rule: notMatchesRegex, value: 0, param: "(19|20)\\d\\d"}
+		  ]
+		) {
+		  verify
+		  noMatch
+		}
+	  }
+	`
+	var resp QueryResponse
+	c.MustPost(query, &resp)
+
+	require.False(t, resp.Verify.Verify)
+	require.Equal(t, []string{"notMatchesRegex"}, resp.Verify.NoMatch)
+}
+
+// TEST CASE 19: Query with constraints on the first and the last character
+func TestQueryWithPositionRules(t *testing.T) {
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	query := `{
+		verify(
+		  password: "1Senha23!"
+		  rules: [
+			{rule: firstCharNotClass, value: 0, param: "digit"},
+			{rule: lastCharNotClass, value: 0, param: "special"},
+			{rule: lastCharClass, value: 0, param: "special"}
+		  ]
+		) {
+		  verify
+		  noMatch
+		}
+	  }
+	`
+	var resp QueryResponse
+	c.MustPost(query, &resp)
+
+	require.False(t, resp.Verify.Verify)
+	require.Equal(t, []string{"firstCharNotClass", "lastCharNotClass"}, resp.Verify.NoMatch)
+}
+
+// TEST CASE 20: Query with a policy defined on the server
+func TestQueryWithPolicy(t *testing.T) {
+	policies := policy.NewMemoryStore(&policy.Policy{
+		Name:    "corporate-v3",
+		Version: 3,
+		Rules:   []utils.Rule{{Rule: "minSize", Value: 12}, {Rule: "minDigit", Value: 2}},
+	})
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{Store: policies}})))
+
+	query := `{
+		verify(password: "Senha1!", policy: "corporate-v3") {
+		  verify
+		  noMatch
+		}
+	  }
+	`
+	var resp QueryResponse
+	c.MustPost(query, &resp)
+
+	require.False(t, resp.Verify.Verify)
+	require.Equal(t, []string{"minSize", "minDigit"}, resp.Verify.NoMatch)
+
+	// an unknown policy, or a policy together with rules, is rejected
+	err := c.Post(`{ verify(password: "Senha1!", policy: "corporate-v4") { verify } }`, &resp)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "the policy 'corporate-v4' does not exist")
+	err = c.Post(`{ verify(password: "Senha1!", policy: "corporate-v3", rules: []) { verify } }`, &resp)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot be given together")
+	err = c.Post(`{ verify(password: "Senha1!") { verify } }`, &resp)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "either the rules or the name of a policy must be given")
+}
+
+// TEST CASE 21: Management of policies through mutations
+func TestPolicyMutations(t *testing.T) {
+	c := newAdminClient(policy.NewMemoryStore())
+
+	type PolicyRule struct {
+		Rule  string
+		Value int
+		Param *string
+	}
+	type Policy struct {
+		Name    string
+		Version int
+		Rules   []PolicyRule
+	}
+
+	var created struct{ CreatePolicy Policy }
+	c.MustPost(`mutation {
+		createPolicy(policy: {name: "corporate", rules: [{rule: minSize, value: 12}]}) { name version rules { rule value param } }
+	}`, &created)
+	require.Equal(t, Policy{Name: "corporate", Version: 1, Rules: []PolicyRule{{Rule: "minSize", Value: 12}}}, created.CreatePolicy)
+
+	var updated struct{ UpdatePolicy Policy }
+	c.MustPost(`mutation {
+		updatePolicy(policy: {name: "corporate", rules: [{rule: minSize, value: 14}, {rule: minDigit, value: 2}]}) { name version }
+	}`, &updated)
+	require.Equal(t, Policy{Name: "corporate", Version: 2}, updated.UpdatePolicy)
+
+	var listed struct {
+		Policies []Policy
+		Policy   *Policy
+	}
+	c.MustPost(`{ policies { name version } policy(name: "corporate") { name version rules { rule value } } }`, &listed)
+	require.Equal(t, []Policy{{Name: "corporate", Version: 2}}, listed.Policies)
+	require.Equal(t, &Policy{Name: "corporate", Version: 2, Rules: []PolicyRule{{Rule: "minSize", Value: 14}, {Rule: "minDigit", Value: 2}}}, listed.Policy)
+
+	var resp QueryResponse
+	c.MustPost(`{ verify(password: "Senha1234567!", policy: "corporate") { verify noMatch } }`, &resp)
+	require.Equal(t, []string{"minSize"}, resp.Verify.NoMatch)
+
+	// a policy with invalid rules, or with the name of an existing policy, is not created
+	err := c.Post(`mutation { createPolicy(policy: {name: "basic", rules: [{rule: maxSize, value: 6}, {rule: minSize, value: 8}]}) { name } }`, &created)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "below the value 8 of the rule 'minSize'")
+	err = c.Post(`mutation { createPolicy(policy: {name: "corporate", rules: [{rule: minSize, value: 8}]}) { name } }`, &created)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "the policy 'corporate' already exists")
+
+	var deleted struct{ DeletePolicy bool }
+	c.MustPost(`mutation { deletePolicy(name: "corporate") }`, &deleted)
+	require.True(t, deleted.DeletePolicy)
+	c.MustPost(`mutation { deletePolicy(name: "corporate") }`, &deleted)
+	require.False(t, deleted.DeletePolicy)
+	var missing struct{ Policy *Policy }
+	c.MustPost(`{ policy(name: "corporate") { name } }`, &missing)
+	require.Nil(t, missing.Policy)
+
+	// the mutations are rejected without the admin token, or when no admin token is configured
+	store := policy.NewMemoryStore()
+	for _, other := range []*client.Client{
+		client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{Store: store, AdminToken: adminToken}}))),
+		client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{Store: store, AdminToken: adminToken}})),
+			client.AddHeader("Authorization", "Bearer wrong-token")),
+	} {
+		err = other.Post(`mutation { createPolicy(policy: {name: "weak", rules: [{rule: minSize, value: 1}]}) { name } }`, &created)
+		require.ErrorContains(t, err, "the policy mutations require the admin token in the Authorization header")
+		err = other.Post(`mutation { deletePolicy(name: "corporate") }`, &deleted)
+		require.ErrorContains(t, err, "the policy mutations require the admin token in the Authorization header")
+	}
+	disabled := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{Store: store}})),
+		client.AddHeader("Authorization", "Bearer "))
+	err = disabled.Post(`mutation { updatePolicy(policy: {name: "corporate", rules: [{rule: minSize, value: 1}]}) { name } }`, &updated)
+	require.ErrorContains(t, err, "the policy mutations are disabled")
+	policies, _ := store.List()
+	require.Empty(t, policies)
+}
+
+// TEST CASE 22: History of the versions of a policy
+func TestPolicyHistory(t *testing.T) {
+	c := newAdminClient(policy.NewMemoryStore())
+
+	type Policy struct {
+		Version   int
+		Author    *string
+		UpdatedAt string
+		Rules     []struct{ Value int }
+	}
+
+	var changed struct {
+		CreatePolicy Policy
+		UpdatePolicy Policy
+	}
+	c.MustPost(`mutation {
+		createPolicy(policy: {name: "corporate", rules: [{rule: minSize, value: 12}], author: "alice"}) { version }
+		updatePolicy(policy: {name: "corporate", rules: [{rule: minSize, value: 14}], author: "bob"}) { version author updatedAt }
+	}`, &changed)
+	require.Equal(t, 2, changed.UpdatePolicy.Version)
+	require.Equal(t, "bob", *changed.UpdatePolicy.Author)
+	require.NotEmpty(t, changed.UpdatePolicy.UpdatedAt)
+
+	// the verify response tells which version of the policy was applied
+	var resp QueryResponse
+	c.MustPost(`{ verify(password: "Senha1234567!", policy: "corporate") { verify policy policyVersion } }`, &resp)
+	require.False(t, resp.Verify.Verify)
+	require.Equal(t, "corporate", *resp.Verify.Policy)
+	require.Equal(t, 2, *resp.Verify.PolicyVersion)
+	var rules_resp QueryResponse
+	c.MustPost(`{ verify(password: "Senha1234567!", rules: [{rule: minSize, value: 12}]) { verify policy policyVersion } }`, &rules_resp)
+	require.Nil(t, rules_resp.Verify.Policy)
+	require.Nil(t, rules_resp.Verify.PolicyVersion)
+
+	var history struct {
+		PolicyHistory []Policy
+		Policy        *Policy
+	}
+	c.MustPost(`{
+		policyHistory(name: "corporate") { version author rules { value } }
+		policy(name: "corporate", version: 1) { version author rules { value } }
+	}`, &history)
+	require.Len(t, history.PolicyHistory, 2)
+	require.Equal(t, "alice", *history.PolicyHistory[0].Author)
+	require.Equal(t, 12, history.PolicyHistory[0].Rules[0].Value)
+	require.Equal(t, 14, history.PolicyHistory[1].Rules[0].Value)
+	require.Equal(t, 1, history.Policy.Version)
+	require.Equal(t, 12, history.Policy.Rules[0].Value)
+
+	// the history is kept after the policy is deleted
+	c.MustPost(`mutation { deletePolicy(name: "corporate") }`, &struct{ DeletePolicy bool }{})
+	var deleted struct {
+		PolicyHistory []Policy
+		Policy        *Policy
+	}
+	c.MustPost(`{ policyHistory(name: "corporate") { version } policy(name: "corporate", version: 2) { version } }`, &deleted)
+	require.Len(t, deleted.PolicyHistory, 2)
+	require.Equal(t, 2, deleted.Policy.Version)
+	var missing struct{ PolicyHistory []Policy }
+	c.MustPost(`{ policyHistory(name: "missing") { version } }`, &missing)
+	require.Empty(t, missing.PolicyHistory)
+}
+
+// TEST CASE 23: Policies that extend other policies
+func TestPolicyInheritance(t *testing.T) {
+	policies := policy.NewMemoryStore(&policy.Policy{
+		Name:    "corporate",
+		Version: 1,
+		Rules:   []utils.Rule{{Rule: "minSize", Value: 12}, {Rule: "maxSize", Value: 64}},
+	})
+	c := newAdminClient(policies)
+
+	type PolicyRule struct {
+		Rule  string
+		Value int
+	}
+	type Policy struct {
+		Extends        *string
+		Rules          []PolicyRule
+		EffectiveRules []PolicyRule
+	}
+
+	var created struct{ CreatePolicy Policy }
+	c.MustPost(`mutation {
+		createPolicy(policy: {name: "finance", extends: "corporate", rules: [{rule: minSize, value: 8}, {rule: minDigit, value: 2}]}) {
+			extends rules { rule value } effectiveRules { rule value }
+		}
+	}`, &created)
+	require.Equal(t, "corporate", *created.CreatePolicy.Extends)
+	require.Equal(t, []PolicyRule{{Rule: "minSize", Value: 8}, {Rule: "minDigit", Value: 2}}, created.CreatePolicy.Rules)
+	require.Equal(t, []PolicyRule{{Rule: "minSize", Value: 12}, {Rule: "maxSize", Value: 64}, {Rule: "minDigit", Value: 2}},
+		created.CreatePolicy.EffectiveRules, "the highest minimum should be kept")
+
+	// the effective rules are applied to the password
+	var resp QueryResponse
+	c.MustPost(`{ verify(password: "Senha1!", policy: "finance") { verify noMatch } }`, &resp)
+	require.Equal(t, []string{"minSize", "minDigit"}, resp.Verify.NoMatch)
+
+	// a policy cannot extend a missing policy, have effective rules in conflict or have its base deleted
+	err := c.Post(`mutation { createPolicy(policy: {name: "retail", extends: "missing", rules: []}) { name } }`, &created)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "extends the policy 'missing', which does not exist")
+	err = c.Post(`mutation { createPolicy(policy: {name: "retail", extends: "corporate", rules: [{rule: maxSize, value: 10}]}) { name } }`, &created)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "the effective rules of the policy 'retail' are invalid")
+	err = c.Post(`mutation { updatePolicy(policy: {name: "corporate", rules: [{rule: minSize, value: 12}, {rule: maxDigit, value: 1}]}) { name } }`, &created)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "would invalidate the policy 'finance'")
+	err = c.Post(`mutation { deletePolicy(name: "corporate") }`, &struct{ DeletePolicy bool }{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "extended by the policy 'finance'")
+
+	// an update of the base creates a new version of the policy, while the previous one keeps its rules
+	c.MustPost(`mutation { updatePolicy(policy: {name: "corporate", rules: [{rule: minSize, value: 16}]}) { name } }`, &struct{ UpdatePolicy struct{ Name string } }{})
+	var versions struct {
+		Current  struct{ Version, ExtendsVersion int }
+		Previous struct{ EffectiveRules []PolicyRule }
+	}
+	c.MustPost(`{
+		current: policy(name: "finance") { version extendsVersion }
+		previous: policy(name: "finance", version: 1) { effectiveRules { rule value } }
+	}`, &versions)
+	require.Equal(t, 2, versions.Current.Version)
+	require.Equal(t, 2, versions.Current.ExtendsVersion)
+	require.Equal(t, []PolicyRule{{Rule: "minSize", Value: 12}, {Rule: "maxSize", Value: 64}, {Rule: "minDigit", Value: 2}},
+		versions.Previous.EffectiveRules)
+}
+
+// TEST CASE 24: Validation of a set of rules without a password
+func TestValidatePolicy(t *testing.T) {
+	c := client.New(handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}})))
+
+	type PolicyIssue struct {
+		Rule    *string
+		Message string
+	}
+	var resp struct {
+		ValidatePolicy struct {
+			Valid    bool
+			Errors   []PolicyIssue
+			Warnings []PolicyIssue
+		}
+	}
+	c.MustPost(`{
+		validatePolicy(rules: [
+			{rule: minSize, value: 8},
+			{rule: maxSize, value: 10},
+			{rule: minUppercase, value: 4},
+			{rule: minDigit, value: 4},
+			{rule: minSpecialChars, value: 4},
+			{rule: allowedCharset, value: 0, param: "A-Z0-9"}
+		]) { valid errors { rule message } warnings { rule message } }
+	}`, &resp)
+
+	require.False(t, resp.ValidatePolicy.Valid)
+	require.Len(t, resp.ValidatePolicy.Errors, 2)
+	require.Equal(t, "maxSize", *resp.ValidatePolicy.Errors[0].Rule)
+	require.Contains(t, resp.ValidatePolicy.Errors[0].Message, "require at least 12 characters together")
+	require.Equal(t, "minSpecialChars", *resp.ValidatePolicy.Errors[1].Rule)
+	require.Empty(t, resp.ValidatePolicy.Warnings)
+
+	c.MustPost(`{
+		validatePolicy(rules: [{rule: minSize, value: 12}, {rule: minSize, value: 14}]) { valid errors { rule message } warnings { rule message } }
+	}`, &resp)
+	require.True(t, resp.ValidatePolicy.Valid)
+	require.Empty(t, resp.ValidatePolicy.Errors)
+	require.Len(t, resp.ValidatePolicy.Warnings, 1)
+	require.Contains(t, resp.ValidatePolicy.Warnings[0].Message, "the rule 'minSize' is given 2 times")
+}
@@ -2,7 +2,208 @@
 
 package model
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
 type Password struct {
-	Verify  bool     `json:"verify"`
-	NoMatch []string `json:"noMatch"`
+	Verify  bool          `json:"verify"`
+	NoMatch []string      `json:"noMatch"`
+	Results []*RuleResult `json:"results"`
+	// Strength of the password, from 0 (very weak) to 4 (very strong), regardless of the rules.
+	Score int `json:"score"`
+	// Estimated entropy of the password, in bits.
+	Entropy float64 `json:"entropy"`
+	// Name of the policy whose rules were applied, or null if the rules were given.
+	Policy *string `json:"policy"`
+	// Version of the policy whose rules were applied, or null if the rules were given.
+	PolicyVersion *int `json:"policyVersion"`
+}
+
+// A named set of rules defined on the server, which can be chosen in the verify query.
+type Policy struct {
+	Name string `json:"name"`
+	// Version of the policy, incremented each time its rules are updated.
+	Version int `json:"version"`
+	// Name of the policy whose rules this policy overrides or adds to, if any.
+	Extends *string `json:"extends"`
+	// Version of the policy it extends that this version is merged with, pinned when this version was created.
+	// An update of the policy it extends creates a new version of this policy, pinned to the new version.
+	ExtendsVersion *int `json:"extendsVersion"`
+	// Rules of this version of the policy, without the rules of the policy it extends.
+	Rules []*PolicyRule `json:"rules"`
+	// Rules applied when this version of the policy is chosen: the rules of the pinned version of the policy it extends, merged with the rules of the policy.
+	// Null when the policy it extends cannot be found.
+	EffectiveRules []*PolicyRule `json:"effectiveRules"`
+	// Who created this version of the policy, if known. The policies loaded at startup have no author.
+	Author *string `json:"author"`
+	// When this version of the policy was created, from when its rules were in force.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// A policy to be created or updated, with its name and rules.
+type PolicyInput struct {
+	Name string `json:"name"`
+	// Name of a policy whose rules this policy overrides or adds to. The rules can then be empty.
+	Extends *string      `json:"extends"`
+	Rules   []*RuleInput `json:"rules"`
+	// Who creates or updates the policy, kept in its history.
+	Author *string `json:"author"`
+}
+
+// A problem found by validatePolicy in a set of rules.
+type PolicyIssue struct {
+	// Rule the problem is about, or null if it is about the rules as a whole.
+	Rule    *string `json:"rule"`
+	Message string  `json:"message"`
+}
+
+// A rule of a policy, with its configuration value and optional parameter.
+type PolicyRule struct {
+	Rule  RuleName `json:"rule"`
+	Value int      `json:"value"`
+	Param *string  `json:"param"`
+}
+
+// Result of validatePolicy.
+type PolicyValidation struct {
+	// True if there are no errors, so that the rules are accepted and some password can satisfy them.
+	Valid bool `json:"valid"`
+	// Invalid rules and rules that no password can satisfy together (e.g. a maxSize below the sum of the per-class minimums).
+	Errors []*PolicyIssue `json:"errors"`
+	// Rules that are valid but most likely not intended (e.g. a rule given twice, or a minSize below the sum of the per-class minimums).
+	Warnings []*PolicyIssue `json:"warnings"`
+}
+
+// A password validation rule chosen by the user, with its configuration value.
+type RuleInput struct {
+	Rule  RuleName `json:"rule"`
+	Value int      `json:"value"`
+	// Optional parameter, whose meaning depends on the rule. For minSpecialChars and maxSpecialChars, it is the set of special
+	// characters (e.g. "!@#_"), or one of the predefined sets "owasp" and "nonAlphanumeric". For noKeyboardPattern,
+	// it restricts the check to one keyboard layout: "qwerty", "abnt2" or "azerty". For notCommon, "normalize" also
+	// looks up the password without leading and trailing digits and symbols and with leetspeak reverted (e.g. "P@ssw0rd1").
+	// For forbiddenChars and allowedCharset, it is the set of characters, with ranges (e.g. "a-z") and Unicode categories,
+	// scripts or properties (e.g. "\\p{Lu}"). For matchesRegex and notMatchesRegex, it is an RE2 regular expression
+	// (e.g. "^[A-Za-z]"), of at most 256 characters. For firstCharClass, lastCharClass, firstCharNotClass and lastCharNotClass,
+	// it is the class of characters: "upper", "lower", "digit", "special" or "letter".
+	Param *string `json:"param"`
+}
+
+// Outcome of a single rule applied to the password.
+type RuleResult struct {
+	Rule RuleName `json:"rule"`
+	// Value configured for the rule.
+	Required int `json:"required"`
+	// Value measured in the password (e.g. number of digits found).
+	Actual int  `json:"actual"`
+	Passed bool `json:"passed"`
+	// Human readable description of the result, suitable to be shown to the user.
+	Message string `json:"message"`
+	// What made the rule fail, when available (e.g. the common password matched by notCommon).
+	Detail *string `json:"detail"`
+}
+
+// Information about the user the password is for, checked by the noUserInfo rule. Every field is optional.
+type UserContextInput struct {
+	Username    *string `json:"username"`
+	Email       *string `json:"email"`
+	FirstName   *string `json:"firstName"`
+	LastName    *string `json:"lastName"`
+	CompanyName *string `json:"companyName"`
+}
+
+// Names of the password validation rules accepted by the API.
+type RuleName string
+
+const (
+	RuleNameMinSize           RuleName = "minSize"
+	RuleNameMinUppercase      RuleName = "minUppercase"
+	RuleNameMinLowercase      RuleName = "minLowercase"
+	RuleNameMinDigit          RuleName = "minDigit"
+	RuleNameMinSpecialChars   RuleName = "minSpecialChars"
+	RuleNameNoRepeted         RuleName = "noRepeted"
+	RuleNameMaxSize           RuleName = "maxSize"
+	RuleNameMaxUppercase      RuleName = "maxUppercase"
+	RuleNameMaxLowercase      RuleName = "maxLowercase"
+	RuleNameMaxDigit          RuleName = "maxDigit"
+	RuleNameMaxSpecialChars   RuleName = "maxSpecialChars"
+	RuleNameNoSequential      RuleName = "noSequential"
+	RuleNameNoKeyboardPattern RuleName = "noKeyboardPattern"
+	RuleNameNotCommon         RuleName = "notCommon"
+	RuleNameNotBreached       RuleName = "notBreached"
+	RuleNameNoUserInfo        RuleName = "noUserInfo"
+	RuleNameMinEditDistance   RuleName = "minEditDistance"
+	RuleNameMinUniqueChars    RuleName = "minUniqueChars"
+	RuleNameMinCharClasses    RuleName = "minCharClasses"
+	RuleNameForbiddenChars    RuleName = "forbiddenChars"
+	RuleNameAllowedCharset    RuleName = "allowedCharset"
+	RuleNameMatchesRegex      RuleName = "matchesRegex"
+	RuleNameNotMatchesRegex   RuleName = "notMatchesRegex"
+	RuleNameFirstCharClass    RuleName = "firstCharClass"
+	RuleNameLastCharClass     RuleName = "lastCharClass"
+	RuleNameFirstCharNotClass RuleName = "firstCharNotClass"
+	RuleNameLastCharNotClass  RuleName = "lastCharNotClass"
+)
+
+var AllRuleName = []RuleName{
+	RuleNameMinSize,
+	RuleNameMinUppercase,
+	RuleNameMinLowercase,
+	RuleNameMinDigit,
+	RuleNameMinSpecialChars,
+	RuleNameNoRepeted,
+	RuleNameMaxSize,
+	RuleNameMaxUppercase,
+	RuleNameMaxLowercase,
+	RuleNameMaxDigit,
+	RuleNameMaxSpecialChars,
+	RuleNameNoSequential,
+	RuleNameNoKeyboardPattern,
+	RuleNameNotCommon,
+	RuleNameNotBreached,
+	RuleNameNoUserInfo,
+	RuleNameMinEditDistance,
+	RuleNameMinUniqueChars,
+	RuleNameMinCharClasses,
+	RuleNameForbiddenChars,
+	RuleNameAllowedCharset,
+	RuleNameMatchesRegex,
+	RuleNameNotMatchesRegex,
+	RuleNameFirstCharClass,
+	RuleNameLastCharClass,
+	RuleNameFirstCharNotClass,
+	RuleNameLastCharNotClass,
+}
+
+func (e RuleName) IsValid() bool {
+	switch e {
+	case RuleNameMinSize, RuleNameMinUppercase, RuleNameMinLowercase, RuleNameMinDigit, RuleNameMinSpecialChars, RuleNameNoRepeted, RuleNameMaxSize, RuleNameMaxUppercase, RuleNameMaxLowercase, RuleNameMaxDigit, RuleNameMaxSpecialChars, RuleNameNoSequential, RuleNameNoKeyboardPattern, RuleNameNotCommon, RuleNameNotBreached, RuleNameNoUserInfo, RuleNameMinEditDistance, RuleNameMinUniqueChars, RuleNameMinCharClasses, RuleNameForbiddenChars, RuleNameAllowedCharset, RuleNameMatchesRegex, RuleNameNotMatchesRegex, RuleNameFirstCharClass, RuleNameLastCharClass, RuleNameFirstCharNotClass, RuleNameLastCharNotClass:
+		return true
+	}
+	return false
+}
+
+func (e RuleName) String() string {
+	return string(e)
+}
+
+func (e *RuleName) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RuleName(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RuleName", str)
+	}
+	return nil
+}
+
+func (e RuleName) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
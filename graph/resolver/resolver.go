@@ -1,6 +1,15 @@
 package resolver
 
+import "graphpass/policy"
+
 // This file will not be regenerated automatically.
 // It serves as dependency injection for your app, add any dependencies you require here.
 
-type Resolver struct{}
+type Resolver struct {
+	// Store is the store of the policies that can be chosen in the verify query and managed through the
+	// policy mutations. If nil, no policy can be chosen or managed.
+	Store policy.Store
+	// AdminToken is the token that authorizes the policy mutations, sent as "Authorization: Bearer <token>".
+	// If empty, the policy mutations are rejected.
+	AdminToken string
+}
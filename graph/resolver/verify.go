@@ -2,32 +2,345 @@ package resolver
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
 	"graphpass/graph"
 	"graphpass/graph/model"
 	"graphpass/password"
+	"graphpass/policy"
 	"graphpass/utils"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
 )
 
 // The "Verify" function is a resolver that will handle the "verify" query from the user.
-// It first maps the user-supplied rules to a struct using the MapToStruct function. Subsequently,
-// the entire password validation process is done by the ValidPassword function, and if there are no
-// errors, we build the response according to the Password format defined in the schema and return to the user.
-func (r *queryResolver) Verify(ctx context.Context, pass string, rules []map[string]interface{}) (*model.Password, error) {
-	rules_struct, err := utils.MapToStruct(rules)
+// The shape of the rules was already validated by the GraphQL schema (RuleInput type), so
+// it first maps the user-supplied rules to a struct using the MapToStruct function, or takes the rules
+// of the policy chosen by the user. Subsequently, the entire password validation process is done by the
+// CheckPassword function, and if there are no errors, we build the response according to the Password
+// format defined in the schema, along with the strength estimate of the password, and return to the user.
+// The optional user context is passed to the rules that check the password against the information about
+// the user, and the optional previous passwords to the rules that compare the password with them. When a
+// policy is chosen, the response tells which version of it was applied.
+func (r *queryResolver) Verify(ctx context.Context, pass string, rules []*model.RuleInput, unicode *bool, user_context *model.UserContextInput, previous_passwords []string, policy_name *string) (*model.Password, error) {
+	if len(previous_passwords) > password.MaxPreviousPasswords {
+		return nil, fmt.Errorf("at most %d previous passwords are accepted", password.MaxPreviousPasswords)
+	}
+	rules_struct, applied, err := r.resolveRules(rules, policy_name)
 	if err != nil {
-		return nil, err // if a error occours on MapToStruct, the error is immediately returned to user
+		return nil, err // if a error occours when resolving the rules, the error is immediately returned to user
 	}
 
-	verify, noMatched := password.ValidPassword(pass, rules_struct)
+	opts := password.Options{
+		Unicode:           unicode != nil && *unicode,
+		User:              utils.MapToUserInfo(user_context),
+		PreviousPasswords: previous_passwords,
+	}
+	results := password.CheckPassword(pass, rules_struct, opts)
+	verify, noMatched := password.Summarize(results)
+	score, entropy := password.Strength(pass)
 
 	response := &model.Password{
 		Verify:  verify,
 		NoMatch: noMatched,
+		Results: toRuleResults(results),
+		Score:   score,
+		Entropy: entropy,
+	}
+	if applied != nil {
+		response.Policy = &applied.Name
+		response.PolicyVersion = &applied.Version
 	}
 	return response, nil
 }
 
+// returns the rules to be applied to the password: the rules sent by the user, or the effective rules of the
+// current version of the policy chosen by the user, together with that policy. The rules of a policy were
+// already checked when the policy was stored.
+func (r *queryResolver) resolveRules(rules []*model.RuleInput, policy_name *string) ([]utils.Rule, *policy.Policy, error) {
+	switch {
+	case rules != nil && policy_name != nil:
+		return nil, nil, fmt.Errorf("the rules and a policy cannot be given together")
+	case policy_name != nil:
+		if r.Store == nil {
+			return nil, nil, fmt.Errorf("the policy '%s' does not exist. No policies are configured", *policy_name)
+		}
+		chosen, err := r.Store.Get(*policy_name)
+		if errors.Is(err, policy.ErrNotFound) {
+			return nil, nil, fmt.Errorf("the policy '%s' does not exist", *policy_name)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		effective, err := policy.Effective(chosen, policy.Versions(r.Store))
+		if err != nil {
+			return nil, nil, err
+		}
+		return effective, chosen, nil
+	case rules != nil:
+		rules_struct, err := utils.MapToStruct(rules)
+		return rules_struct, nil, err
+	}
+	return nil, nil, fmt.Errorf("either the rules or the name of a policy must be given")
+}
+
+// Policies lists every policy of the store.
+func (r *queryResolver) Policies(ctx context.Context) ([]*model.Policy, error) {
+	if r.Store == nil {
+		return []*model.Policy{}, nil
+	}
+	policies, err := r.Store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	model_policies := make([]*model.Policy, 0, len(policies))
+	for _, stored := range policies {
+		model_policies = append(model_policies, r.toModelPolicy(stored))
+	}
+	return model_policies, nil
+}
+
+// Policy returns the current or the given version of the policy with the given name, or null if there is
+// no such policy or version.
+func (r *queryResolver) Policy(ctx context.Context, name string, version *int) (*model.Policy, error) {
+	if r.Store == nil {
+		return nil, nil
+	}
+	var stored *policy.Policy
+	var err error
+	if version != nil {
+		stored, err = r.Store.GetVersion(name, *version)
+	} else {
+		stored, err = r.Store.Get(name)
+	}
+	if errors.Is(err, policy.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toModelPolicy(stored), nil
+}
+
+// PolicyHistory lists every version of the policy with the given name, or no version if the policy never
+// existed.
+func (r *queryResolver) PolicyHistory(ctx context.Context, name string) ([]*model.Policy, error) {
+	if r.Store == nil {
+		return []*model.Policy{}, nil
+	}
+	history, err := r.Store.History(name)
+	if errors.Is(err, policy.ErrNotFound) {
+		return []*model.Policy{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	model_policies := make([]*model.Policy, 0, len(history))
+	for _, stored := range history {
+		model_policies = append(model_policies, r.toModelPolicy(stored))
+	}
+	return model_policies, nil
+}
+
+// ValidatePolicy checks a set of rules without a password, returning its errors and warnings. The rules are not
+// rejected with an error, so that every problem is reported at once.
+func (r *queryResolver) ValidatePolicy(ctx context.Context, rules []*model.RuleInput, unicode *bool) (*model.PolicyValidation, error) {
+	lint := utils.LintRules(utils.MapToRules(rules), unicode != nil && *unicode)
+	return &model.PolicyValidation{
+		Valid:    len(lint.Errors) == 0,
+		Errors:   toPolicyIssues(lint.Errors),
+		Warnings: toPolicyIssues(lint.Warnings),
+	}, nil
+}
+
+// CreatePolicy creates a policy at version 1, after checking its name and its effective rules. If a policy
+// with the same name was deleted, the new policy continues its history.
+func (r *mutationResolver) CreatePolicy(ctx context.Context, input model.PolicyInput) (*model.Policy, error) {
+	if err := r.authorize(ctx); err != nil {
+		return nil, err
+	}
+	created, err := toPolicy(input)
+	if err != nil {
+		return nil, err
+	}
+	if r.Store == nil {
+		return nil, errNoStore
+	}
+	stored, err := policy.Create(r.Store, created)
+	if errors.Is(err, policy.ErrExists) {
+		return nil, fmt.Errorf("the policy '%s' already exists", created.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toModelPolicy(stored), nil
+}
+
+// UpdatePolicy replaces the rules of an existing policy with a new version, after checking its effective rules
+// and the effective rules of the policies that extend it, which get a new version pinned to the new version
+// of the policy. The previous versions are kept in the history of the policy.
+func (r *mutationResolver) UpdatePolicy(ctx context.Context, input model.PolicyInput) (*model.Policy, error) {
+	if err := r.authorize(ctx); err != nil {
+		return nil, err
+	}
+	changed, err := toPolicy(input)
+	if err != nil {
+		return nil, err
+	}
+	if r.Store == nil {
+		return nil, errNoStore
+	}
+	updated, err := policy.Update(r.Store, changed)
+	if errors.Is(err, policy.ErrNotFound) {
+		return nil, fmt.Errorf("the policy '%s' does not exist", changed.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toModelPolicy(updated), nil
+}
+
+// DeletePolicy deletes a policy, keeping its history, returning whether it existed. A policy extended by
+// another policy cannot be deleted.
+func (r *mutationResolver) DeletePolicy(ctx context.Context, name string) (bool, error) {
+	if err := r.authorize(ctx); err != nil {
+		return false, err
+	}
+	if r.Store == nil {
+		return false, errNoStore
+	}
+	err := policy.Delete(r.Store, name)
+	if errors.Is(err, policy.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// returned when a policy is chosen or managed but the resolver has no store of policies
+var errNoStore = errors.New("no store of policies is configured")
+
+// verifies that the request of a policy mutation carries the admin token in its Authorization header. The
+// tokens are compared in constant time, so that the time of the comparison does not tell how much of a
+// guessed token is right.
+func (r *mutationResolver) authorize(ctx context.Context) error {
+	if r.AdminToken == "" {
+		return errors.New("the policy mutations are disabled, since no admin token is configured")
+	}
+	header := ""
+	if graphql.HasOperationContext(ctx) {
+		header = graphql.GetOperationContext(ctx).Headers.Get("Authorization")
+	}
+	if subtle.ConstantTimeCompare([]byte(header), []byte("Bearer "+r.AdminToken)) != 1 {
+		return errors.New("the policy mutations require the admin token in the Authorization header")
+	}
+	return nil
+}
+
+// converts a policy received from the user from the PolicyInput type generated by gqlgen to a policy at
+// version 1, updated now by its optional author, checking its name and rules
+func toPolicy(input model.PolicyInput) (*policy.Policy, error) {
+	rules, err := utils.MapToStruct(input.Rules)
+	if err != nil {
+		return nil, err
+	}
+	converted := &policy.Policy{Name: input.Name, Version: 1, Rules: rules, UpdatedAt: time.Now().UTC()}
+	if input.Author != nil {
+		converted.Author = *input.Author
+	}
+	if input.Extends != nil {
+		converted.Extends = *input.Extends
+	}
+	if err := converted.Validate(); err != nil {
+		return nil, err
+	}
+	return converted, nil
+}
+
+// converts the rules of a policy to the PolicyRule format defined in the schema
+func toPolicyRules(policy_rules []utils.Rule) []*model.PolicyRule {
+	rules := make([]*model.PolicyRule, 0, len(policy_rules))
+	for _, rule := range policy_rules {
+		policy_rule := &model.PolicyRule{Rule: model.RuleName(rule.Rule), Value: rule.Value}
+		if rule.Param != "" {
+			param := rule.Param
+			policy_rule.Param = &param
+		}
+		rules = append(rules, policy_rule)
+	}
+	return rules
+}
+
+// converts a policy of the store to the Policy format defined in the schema, with its effective rules from the
+// pinned versions of the policies it extends, which are null when one of them cannot be found
+func (r *Resolver) toModelPolicy(stored *policy.Policy) *model.Policy {
+	converted := &model.Policy{
+		Name:      stored.Name,
+		Version:   stored.Version,
+		Rules:     toPolicyRules(stored.Rules),
+		UpdatedAt: stored.UpdatedAt,
+	}
+	if stored.Extends != "" {
+		extends := stored.Extends
+		converted.Extends = &extends
+	}
+	if stored.BaseVersion != 0 {
+		base_version := stored.BaseVersion
+		converted.ExtendsVersion = &base_version
+	}
+	if stored.Author != "" {
+		author := stored.Author
+		converted.Author = &author
+	}
+	if effective, err := policy.Effective(stored, policy.Versions(r.Store)); err == nil {
+		converted.EffectiveRules = toPolicyRules(effective)
+	}
+	return converted
+}
+
+// converts the issues found in a set of rules to the PolicyIssue format defined in the schema
+func toPolicyIssues(issues []utils.Issue) []*model.PolicyIssue {
+	policy_issues := make([]*model.PolicyIssue, 0, len(issues))
+	for _, issue := range issues {
+		policy_issue := &model.PolicyIssue{Message: issue.Message}
+		if issue.Rule != "" {
+			rule := issue.Rule
+			policy_issue.Rule = &rule
+		}
+		policy_issues = append(policy_issues, policy_issue)
+	}
+	return policy_issues
+}
+
+// converts the results of the password validator to the RuleResult format defined in the schema
+func toRuleResults(results []password.Result) []*model.RuleResult {
+	rule_results := make([]*model.RuleResult, 0, len(results))
+
+	for _, result := range results {
+		rule_result := &model.RuleResult{
+			Rule:     model.RuleName(result.Rule),
+			Required: result.Required,
+			Actual:   result.Actual,
+			Passed:   result.Passed,
+			Message:  result.Message,
+		}
+		if result.Detail != "" {
+			detail := result.Detail
+			rule_result.Detail = &detail
+		}
+		rule_results = append(rule_results, rule_result)
+	}
+	return rule_results
+}
+
+// genered by gqlgen
+func (r *Resolver) Mutation() graph.MutationResolver { return &mutationResolver{r} }
+
 // genered by gqlgen
 func (r *Resolver) Query() graph.QueryResolver { return &queryResolver{r} }
 
+type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
@@ -0,0 +1,247 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// parameter of the notCommon rule that enables the normalization of the password before the lookup
+const NormalizeCommon = "normalize"
+
+// common substitutions of letters by digits and symbols (leetspeak), in lowercase. Some characters
+// stand for more than one letter, and every possibility is looked up.
+var leetSubstitutions = map[rune][]rune{
+	'@': {'a'},
+	'4': {'a'},
+	'0': {'o'},
+	'1': {'l', 'i'},
+	'!': {'i'},
+	'$': {'s'},
+	'5': {'s'},
+	'3': {'e'},
+	'7': {'t'},
+}
+
+// maximum number of forms of a password generated by the leetspeak normalization, which grows
+// exponentially with the characters that stand for more than one letter
+const maxLeetForms = 64
+
+// Blocklist is a list of common passwords (e.g. the most used passwords found in leaks). The passwords
+// are kept in memory as a sorted slice without duplicates, in lowercase, so that a lookup is a binary
+// search and the comparison is case-insensitive.
+type Blocklist struct {
+	passwords []string
+}
+
+// NewBlocklist creates a blocklist with the given passwords. Empty passwords are ignored.
+func NewBlocklist(passwords []string) *Blocklist {
+	normalized := make([]string, 0, len(passwords))
+	for _, password := range passwords {
+		if password != "" {
+			normalized = append(normalized, strings.ToLower(password))
+		}
+	}
+	sort.Strings(normalized)
+
+	// removes the duplicates, which are next to each other after sorting
+	unique := normalized[:0]
+	for i, password := range normalized {
+		if i == 0 || password != normalized[i-1] {
+			unique = append(unique, password)
+		}
+	}
+	return &Blocklist{passwords: unique}
+}
+
+// LoadBlocklist reads a blocklist from a text file with one password per line, the format of the
+// common password lists usually distributed. Empty lines are ignored.
+func LoadBlocklist(path string) (*Blocklist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the blocklist: %w", err)
+	}
+	defer file.Close()
+
+	var passwords []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		passwords = append(passwords, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read the blocklist %s: %w", path, err)
+	}
+	return NewBlocklist(passwords), nil
+}
+
+// Contains checks, case-insensitively, if the password is in the blocklist. A nil blocklist is empty.
+func (b *Blocklist) Contains(password string) bool {
+	if b == nil {
+		return false
+	}
+	password = strings.ToLower(password)
+	i := sort.SearchStrings(b.passwords, password)
+	return i < len(b.passwords) && b.passwords[i] == password
+}
+
+// Len returns the number of passwords in the blocklist.
+func (b *Blocklist) Len() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.passwords)
+}
+
+// returns the forms of a word with its leetspeak substitutions reverted (e.g. "p@$$w0rd" -> "password").
+// When a character stands for more than one letter, there is a form for each of them.
+func revertLeet(word string) []string {
+	forms := []string{""}
+	for _, char := range word {
+		letters, ok := leetSubstitutions[char]
+		if !ok {
+			letters = []rune{char}
+		}
+		if len(forms)*len(letters) > maxLeetForms {
+			letters = letters[:1]
+		}
+
+		next := make([]string, 0, len(forms)*len(letters))
+		for _, form := range forms {
+			for _, letter := range letters {
+				next = append(next, form+string(letter))
+			}
+		}
+		forms = next
+	}
+	return forms
+}
+
+// longest password, in characters, that is normalized by the notCommon rule. A longer password is only looked
+// up as it is, since it is far longer than the common passwords and the normalization of arbitrarily long
+// passwords would be an easy way to exhaust the server.
+const maxNormalizedLength = 64
+
+// most characters stripped from the leading or the trailing run of digits and symbols of a password one at a
+// time by the normalization, besides the whole run (e.g. "!1L0v3Y0u" -> "1L0v3Y0u" -> "iloveyou")
+const maxStripped = 3
+
+// returns the numbers of characters stripped from a run of digits and symbols with the given length by the
+// normalization: none, up to maxStripped, and the whole run
+func strippedLengths(run int) []int {
+	lengths := []int{}
+	for length := 0; length <= run && length <= maxStripped; length++ {
+		lengths = append(lengths, length)
+	}
+	if run > maxStripped {
+		lengths = append(lengths, run)
+	}
+	return lengths
+}
+
+// visits the normalized forms of a password looked up in the blocklist, until the visit returns true: the
+// password in lowercase, with its leetspeak substitutions reverted, and without some of its leading and
+// trailing digits and symbols (e.g. "P@ssw0rd123!" -> "password"). Each run is stripped by up to maxStripped
+// characters or as a whole, and the least stripped forms come first. The number of forms is bounded, and
+// there is none for a password without letters or longer than maxNormalizedLength. Returns the form for which
+// the visit returned true.
+func visitNormalizedForms(password string, visit func(string) bool) string {
+	chars := []rune(strings.ToLower(password))
+	if len(chars) > maxNormalizedLength {
+		return ""
+	}
+	isLetter := func(i int) bool { return unicode.IsLetter(chars[i]) }
+
+	// number of digits and symbols at the start and at the end of the password
+	leading := 0
+	for leading < len(chars) && !isLetter(leading) {
+		leading++
+	}
+	if leading == len(chars) {
+		return ""
+	}
+	trailing := 0
+	for !isLetter(len(chars) - 1 - trailing) {
+		trailing++
+	}
+
+	type strip struct{ start, end int }
+	var strips []strip
+	for _, start := range strippedLengths(leading) {
+		for _, end := range strippedLengths(trailing) {
+			strips = append(strips, strip{start: start, end: end})
+		}
+	}
+	sort.SliceStable(strips, func(i, j int) bool {
+		return strips[i].start+strips[i].end < strips[j].start+strips[j].end
+	})
+
+	for _, stripped := range strips {
+		for _, form := range revertLeet(string(chars[stripped.start : len(chars)-stripped.end])) {
+			if visit(form) {
+				return form
+			}
+		}
+	}
+	return ""
+}
+
+// notCommonRule is the Rule implementation of the notCommon rule, which rejects the passwords found
+// in a blocklist. The value of the rule is ignored. With the parameter "normalize", the password is
+// also looked up in its normalized forms, so that "P@ssw0rd1!" matches "password" (see visitNormalizedForms).
+type notCommonRule struct {
+	blocklist *Blocklist
+}
+
+// NotCommon builds the notCommon rule backed by the given blocklist. The built-in notCommon rule has no
+// blocklist and is rejected by CheckConfig, so it must be registered again with the blocklist loaded at
+// startup, e.g.:
+//
+//	password.Register("notCommon", password.NotCommon(blocklist))
+func NotCommon(blocklist *Blocklist) Rule {
+	return notCommonRule{blocklist: blocklist}
+}
+
+// Check looks for the password in the blocklist. Without a blocklist, e.g. for a policy stored before the
+// server was restarted without one, the password fails the rule, so that it is never silently accepted.
+func (r notCommonRule) Check(password string, config RuleConfig, opts Options) Result {
+	result := Result{Rule: config.Rule, Required: config.Value}
+	if r.blocklist == nil {
+		result.Message = "the password could not be checked: no list of common passwords is configured"
+		return result
+	}
+
+	result.Passed = true
+	result.Message = fmt.Sprintf("the password is not in the list of %d common passwords", r.blocklist.Len())
+	if r.blocklist.Contains(password) {
+		result.Actual = 1
+		result.Passed = false
+		result.Message = "the password is in the list of common passwords"
+		return result
+	}
+
+	if config.Param == NormalizeCommon {
+		if form := visitNormalizedForms(password, r.blocklist.Contains); form != "" {
+			result.Actual = 1
+			result.Passed = false
+			result.Message = fmt.Sprintf("the password is a variation of the common password '%s'", form)
+			result.Detail = form
+		}
+	}
+	return result
+}
+
+// CheckConfig verifies that the rule has a blocklist, so that the rule never accepts every password when
+// no blocklist is configured, and that the parameter of the rule, when given, enables the normalization.
+func (r notCommonRule) CheckConfig(config RuleConfig) error {
+	if r.blocklist == nil {
+		return fmt.Errorf("the rule '%s' is not available: no list of common passwords is configured", config.Rule)
+	}
+	if config.Param != "" && config.Param != NormalizeCommon {
+		return fmt.Errorf("the parameter '%s' of the rule '%s' is invalid. The only accepted parameter is '%s'",
+			config.Param, config.Rule, NormalizeCommon)
+	}
+	return nil
+}
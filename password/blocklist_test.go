@@ -0,0 +1,146 @@
+// unit tests to the blocklist of common passwords
+
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests the lookup of passwords in a blocklist
+func TestBlocklistContains(t *testing.T) {
+	blocklist := NewBlocklist([]string{"123456", "password", "qwerty", "Password", "", "iloveyou"})
+
+	assert.Equal(t, 4, blocklist.Len(), "the duplicates and empty passwords should be removed")
+
+	tests := []struct {
+		password_input string
+		want_output    bool
+	}{
+		{password_input: "password", want_output: true},
+		{password_input: "PassWord", want_output: true},
+		{password_input: "123456", want_output: true},
+		{password_input: "1234567", want_output: false},
+		{password_input: "", want_output: false},
+		{password_input: "zzz", want_output: false},
+	}
+
+	for _, test := range tests {
+		result := blocklist.Contains(test.password_input)
+		assert.Equal(t, test.want_output, result,
+			"it was expected that the presence of '%s' in the blocklist would be %t", test.password_input, test.want_output)
+	}
+
+	var empty *Blocklist
+	assert.False(t, empty.Contains("password"))
+	assert.Equal(t, 0, empty.Len())
+}
+
+// Tests the loading of a blocklist from a file
+func TestLoadBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "common.txt")
+	require.Nil(t, os.WriteFile(path, []byte("123456\r\npassword\n\nqwerty\n"), 0o600))
+
+	blocklist, err := LoadBlocklist(path)
+
+	require.Nil(t, err)
+	assert.Equal(t, 3, blocklist.Len())
+	assert.True(t, blocklist.Contains("password"))
+
+	_, err = LoadBlocklist(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.NotNil(t, err)
+}
+
+// Tests the notCommon rule
+func TestNotCommon(t *testing.T) {
+	rule := NotCommon(NewBlocklist([]string{"password", "qwerty"}))
+	config := RuleConfig{Rule: "notCommon", Value: 0}
+
+	assert.Equal(t, Result{
+		Rule:    "notCommon",
+		Actual:  1,
+		Passed:  false,
+		Message: "the password is in the list of common passwords",
+	}, rule.Check("Password", config, Options{}))
+	assert.Equal(t, Result{
+		Rule:    "notCommon",
+		Passed:  true,
+		Message: "the password is not in the list of 2 common passwords",
+	}, rule.Check("x7#Qm!2vLp9@", config, Options{}))
+}
+
+// returns every normalized form of a password looked up by the notCommon rule
+func normalizedForms(password string) []string {
+	var forms []string
+	visitNormalizedForms(password, func(form string) bool {
+		forms = append(forms, form)
+		return false
+	})
+	return forms
+}
+
+// Tests the normalized forms of a password looked up by the notCommon rule
+func TestNormalizedForms(t *testing.T) {
+	assert.Equal(t, []string{"password"}, normalizedForms("P@$$w0rd"))
+	assert.Equal(t, []string{"llove", "ilove", "love"}, normalizedForms("1love"),
+		"the digit 1 should stand for both 'i' and 'l'")
+	assert.Equal(t, []string{"qwertyi", "qwerty"}, normalizedForms("qwerty!"),
+		"the trailing symbols should be stripped only after the unstripped form")
+	assert.Contains(t, normalizedForms("2023Dragon!!"), "dragon")
+	assert.Empty(t, normalizedForms("2023!"), "a password without letters should have no normalized form")
+	assert.LessOrEqual(t, len(revertLeet("1111111111")), maxLeetForms)
+}
+
+// Tests that the normalization of long passwords is bounded
+func TestNormalizedFormsOfLongPasswords(t *testing.T) {
+	padded := strings.Repeat("1", 31) + "a" + strings.Repeat("1", 32)
+	assert.LessOrEqual(t, len(normalizedForms(padded)), (maxStripped+2)*(maxStripped+2)*maxLeetForms,
+		"the runs should be stripped by a bounded number of characters")
+
+	long := strings.Repeat("1", 100) + "a" + strings.Repeat("1", 100)
+	assert.Empty(t, normalizedForms(long), "a password longer than the limit should not be normalized")
+
+	rule := NotCommon(NewBlocklist([]string{"password"}))
+	result := rule.Check(strings.Repeat("1!", 5000)+"a", RuleConfig{Rule: "notCommon", Param: NormalizeCommon}, Options{})
+	assert.True(t, result.Passed)
+	assert.Empty(t, normalizedForms(strings.Repeat("1!", 5000)+"a"))
+}
+
+// Tests the notCommon rule with the normalization of the password
+func TestNotCommonNormalized(t *testing.T) {
+	rule := NotCommon(NewBlocklist([]string{"password", "iloveyou", "dragon"}))
+	config := RuleConfig{Rule: "notCommon", Value: 0, Param: NormalizeCommon}
+
+	tests := []struct {
+		password_input string
+		want_output    string
+	}{
+		{password_input: "P@ssw0rd", want_output: "password"},
+		{password_input: "P@ssw0rd123!", want_output: "password"},
+		{password_input: "!1L0v3Y0u", want_output: "iloveyou"},
+		{password_input: "2023Dr@g0n", want_output: "dragon"},
+		{password_input: "x7#Qm!2vLp9@", want_output: ""},
+	}
+
+	for _, test := range tests {
+		result := rule.Check(test.password_input, config, Options{})
+		assert.Equal(t, test.want_output == "", result.Passed,
+			"it was expected that '%s' would match the common password '%s'", test.password_input, test.want_output)
+		assert.Equal(t, test.want_output, result.Detail)
+	}
+
+	result := rule.Check("P@ssw0rd", RuleConfig{Rule: "notCommon", Value: 0}, Options{})
+	assert.True(t, result.Passed, "the password should not be normalized without the parameter")
+
+	assert.Nil(t, rule.(ConfigChecker).CheckConfig(config))
+	assert.NotNil(t, rule.(ConfigChecker).CheckConfig(RuleConfig{Rule: "notCommon", Param: "leet"}))
+	assert.NotNil(t, NotCommon(nil).(ConfigChecker).CheckConfig(RuleConfig{Rule: "notCommon"}),
+		"the rule should not be available without a blocklist")
+	assert.Equal(t, Result{Rule: "notCommon", Message: "the password could not be checked: no list of common passwords is configured"},
+		NotCommon(nil).Check("x7#Qm!2vLp9@", RuleConfig{Rule: "notCommon"}, Options{}), "the rule should fail without a blocklist")
+}
@@ -0,0 +1,184 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// length of the prefix of the SHA-1 hash sent to a range source. Only the prefix of the hash of the
+// password leaves the validator (k-anonymity), and the source answers with every suffix of the hashes
+// that start with it.
+const hashPrefixLength = 5
+
+// timeout of the requests made by an HTTPRangeSource without a client of its own
+const defaultRangeTimeout = 10 * time.Second
+
+// RangeSource is a source of breached password hashes, in the format of the range API of Have I Been
+// Pwned: given the first 5 hexadecimal characters of a SHA-1 hash, Range returns the list of the
+// breached hashes that start with them, one per line in the format SUFFIX:COUNT, where SUFFIX is the
+// rest of the hash and COUNT is the number of times the password was found in breaches.
+type RangeSource interface {
+	Range(prefix string) (io.ReadCloser, error)
+}
+
+// DirRangeSource is a RangeSource that reads a local mirror of the range API, a directory with one
+// file per prefix named after it (e.g. 5BAA6.txt). A missing file means that no breached hash starts
+// with the prefix.
+type DirRangeSource struct {
+	dir string
+}
+
+// NewDirRangeSource creates a RangeSource for the mirror in the given directory, which must exist.
+func NewDirRangeSource(dir string) (*DirRangeSource, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the breached passwords directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("the breached passwords directory %s is not a directory", dir)
+	}
+	return &DirRangeSource{dir: dir}, nil
+}
+
+// Range opens the file of the prefix.
+func (s *DirRangeSource) Range(prefix string) (io.ReadCloser, error) {
+	file, err := os.Open(filepath.Join(s.dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	return file, err
+}
+
+// HTTPRangeSource is a RangeSource that requests the ranges from a server implementing the range API
+// (e.g. a local stand-in of https://api.pwnedpasswords.com), at BaseURL/range/PREFIX.
+type HTTPRangeSource struct {
+	BaseURL string
+	// Client used for the requests. If nil, a client with a timeout of 10 seconds is used.
+	Client *http.Client
+}
+
+// Range requests the range of the prefix.
+func (s *HTTPRangeSource) Range(prefix string) (io.ReadCloser, error) {
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: defaultRangeTimeout}
+	}
+
+	response, err := client.Get(strings.TrimSuffix(s.BaseURL, "/") + "/range/" + prefix)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("the range API answered with status %s", response.Status)
+	}
+	return response.Body, nil
+}
+
+// splits the uppercase hexadecimal SHA-1 hash of the password into the prefix sent to the range
+// source and the suffix looked up in the range
+func hashPassword(password string) (string, string) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return hash[:hashPrefixLength], hash[hashPrefixLength:]
+}
+
+// returns how many times the hash with the given suffix was found in breaches, according to a range
+// in the format SUFFIX:COUNT, or 0 if the suffix is not in the range
+func breachCount(hashRange io.Reader, suffix string) (int, error) {
+	scanner := bufio.NewScanner(hashRange)
+	for scanner.Scan() {
+		line_suffix, count, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !found || !strings.EqualFold(line_suffix, suffix) {
+			continue
+		}
+		return strconv.Atoi(count)
+	}
+	return 0, scanner.Err()
+}
+
+// BreachCount returns how many times the password was found in breaches, according to the range source.
+func BreachCount(source RangeSource, password string) (int, error) {
+	prefix, suffix := hashPassword(password)
+
+	hashRange, err := source.Range(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("could not get the range %s: %w", prefix, err)
+	}
+	defer hashRange.Close()
+	return breachCount(hashRange, suffix)
+}
+
+// notBreachedRule is the Rule implementation of the notBreached rule, which rejects the passwords found
+// in breaches at least as many times as the value of the rule (any time, if the value is 0).
+type notBreachedRule struct {
+	source RangeSource
+}
+
+// NotBreached builds the notBreached rule backed by the given range source. The built-in notBreached rule
+// has no source, so it is only accepted once registered again with the source configured at startup, e.g.:
+//
+//	password.Register("notBreached", password.NotBreached(source))
+func NotBreached(source RangeSource) Rule {
+	return notBreachedRule{source: source}
+}
+
+// returns the number of breaches from which the notBreached rule rejects a password. The value 0 selects the
+// default, which rejects a password found in any breach.
+func breachThreshold(value int) int {
+	if value == 0 {
+		return 1
+	}
+	return value
+}
+
+// Check looks for the hash of the password in the range source. If the source cannot be read, the
+// password is not accepted, since it could not be checked.
+func (r notBreachedRule) Check(password string, config RuleConfig, opts Options) Result {
+	result := Result{Rule: config.Rule, Required: config.Value}
+	if r.source == nil {
+		result.Message = "the password could not be checked: no source of breached passwords is configured"
+		return result
+	}
+
+	count, err := BreachCount(r.source, password)
+	if err != nil {
+		result.Message = fmt.Sprintf("the password could not be checked against breached passwords: %v", err)
+		return result
+	}
+
+	threshold := breachThreshold(config.Value)
+	result.Actual = count
+	result.Passed = count < threshold
+	switch {
+	case count == 0:
+		result.Message = "the password was not found in breaches"
+	case result.Passed:
+		result.Message = fmt.Sprintf("the password was found in breaches %d times, below the threshold of %d", count, threshold)
+	default:
+		result.Message = fmt.Sprintf("the password was found in breaches %d times", count)
+	}
+	return result
+}
+
+// CheckConfig verifies that the rule has a source of breached passwords.
+func (r notBreachedRule) CheckConfig(config RuleConfig) error {
+	if r.source == nil {
+		return fmt.Errorf("the rule '%s' is not available: no source of breached passwords is configured", config.Rule)
+	}
+	if config.Param != "" {
+		return fmt.Errorf("the rule '%s' does not accept a parameter", config.Rule)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// unit tests to the check of breached passwords
+
+package password
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// range of the prefix 5BAA6, which contains the hash of "password"
+const testRange = "1E2AAA439972480CEC7F16C795BBB429372:1\r\n" +
+	"1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n" +
+	"1E4C9B93F3F0682250B6CF8331B7EE68FD9:0\r\n"
+
+// Tests the splitting of the hash of a password into prefix and suffix
+func TestHashPassword(t *testing.T) {
+	prefix, suffix := hashPassword("password")
+
+	assert.Equal(t, "5BAA6", prefix)
+	assert.Equal(t, "1E4C9B93F3F0682250B6CF8331B7EE68FD8", suffix)
+}
+
+// Tests the lookup of a suffix in a range
+func TestBreachCount(t *testing.T) {
+	tests := []struct {
+		suffix      string
+		want_output int
+	}{
+		{suffix: "1E4C9B93F3F0682250B6CF8331B7EE68FD8", want_output: 9545824},
+		{suffix: "1e4c9b93f3f0682250b6cf8331b7ee68fd8", want_output: 9545824},
+		{suffix: "1E2AAA439972480CEC7F16C795BBB429372", want_output: 1},
+		{suffix: "1E4C9B93F3F0682250B6CF8331B7EE68FD9", want_output: 0},
+		{suffix: "0000000000000000000000000000000000", want_output: 0},
+	}
+
+	for _, test := range tests {
+		count, err := breachCount(strings.NewReader(testRange), test.suffix)
+		assert.Nil(t, err)
+		assert.Equal(t, test.want_output, count, "wrong count of the suffix %s", test.suffix)
+	}
+}
+
+// Tests a range source in a local directory
+func TestDirRangeSource(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "5BAA6.txt"), []byte(testRange), 0o600))
+
+	source, err := NewDirRangeSource(dir)
+	require.Nil(t, err)
+
+	count, err := BreachCount(source, "password")
+	assert.Nil(t, err)
+	assert.Equal(t, 9545824, count)
+
+	// there is no file for the prefix of this password
+	count, err = BreachCount(source, "x7#Qm!2vLp9@")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+
+	_, err = NewDirRangeSource(filepath.Join(dir, "missing"))
+	assert.NotNil(t, err)
+}
+
+// Tests a range source served over HTTP
+func TestHTTPRangeSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/range/5BAA6" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testRange))
+	}))
+	defer server.Close()
+
+	source := &HTTPRangeSource{BaseURL: server.URL + "/"}
+
+	count, err := BreachCount(source, "password")
+	assert.Nil(t, err)
+	assert.Equal(t, 9545824, count)
+
+	_, err = BreachCount(source, "x7#Qm!2vLp9@")
+	assert.NotNil(t, err, "an error of the range API must be returned")
+}
+
+// Tests the notBreached rule
+func TestNotBreached(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "5BAA6.txt"), []byte(testRange), 0o600))
+	source, err := NewDirRangeSource(dir)
+	require.Nil(t, err)
+	rule := NotBreached(source)
+
+	result := rule.Check("password", RuleConfig{Rule: "notBreached", Value: 0}, Options{})
+	assert.Equal(t, Result{
+		Rule:    "notBreached",
+		Actual:  9545824,
+		Passed:  false,
+		Message: "the password was found in breaches 9545824 times",
+	}, result)
+
+	result = rule.Check("password", RuleConfig{Rule: "notBreached", Value: 10000000}, Options{})
+	assert.True(t, result.Passed)
+
+	result = rule.Check("x7#Qm!2vLp9@", RuleConfig{Rule: "notBreached", Value: 0}, Options{})
+	assert.True(t, result.Passed)
+	assert.Equal(t, "the password was not found in breaches", result.Message)
+
+	// without a source the rule is not accepted, and never passes
+	assert.NotNil(t, NotBreached(nil).(ConfigChecker).CheckConfig(RuleConfig{Rule: "notBreached"}))
+	assert.False(t, NotBreached(nil).Check("x7#Qm!2vLp9@", RuleConfig{Rule: "notBreached"}, Options{}).Passed)
+}
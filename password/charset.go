@@ -0,0 +1,257 @@
+package password
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// parses the set of characters given as the parameter of the forbiddenChars and allowedCharset rules into the
+// predicate that tells if a character belongs to it. The set is a list of characters, where:
+//   - "a-z" is the range of characters from "a" to "z" ("-" is a literal character at the start and at the end);
+//   - "\p{Name}" is a Unicode category, script or property (e.g. "\p{Lu}", "\p{Latin}", "\p{White_Space}");
+//   - "\\" and "\-" are the literal characters "\" and "-".
+func parseCharset(set string) (func(rune) bool, error) {
+	literals, tables, err := parseCharsetTables(set)
+	if err != nil {
+		return nil, err
+	}
+	return func(char rune) bool {
+		return strings.ContainsRune(literals, char) || unicode.In(char, tables...)
+	}, nil
+}
+
+// parses a set of characters, in the format of parseCharset, into its literal characters and the tables of
+// its ranges and Unicode classes
+func parseCharsetTables(set string) (string, []*unicode.RangeTable, error) {
+	var chars []rune
+	var ranges []unicode.RangeTable
+	var tables []*unicode.RangeTable
+
+	runes := []rune(set)
+	for i := 0; i < len(runes); i++ {
+		char := runes[i]
+		if char == '\\' {
+			if i+1 == len(runes) {
+				return "", nil, fmt.Errorf("the set of characters ends with an incomplete escape")
+			}
+			i++
+			switch runes[i] {
+			case '\\', '-':
+				chars = append(chars, runes[i])
+			case 'p':
+				end := i + 1
+				for end < len(runes) && runes[end] != '}' {
+					end++
+				}
+				if i+1 == len(runes) || runes[i+1] != '{' || end == len(runes) {
+					return "", nil, fmt.Errorf(`the set of characters has an incomplete "\p{Name}"`)
+				}
+				name := string(runes[i+2 : end])
+				table, ok := unicodeTable(name)
+				if !ok {
+					return "", nil, fmt.Errorf("'%s' is not a Unicode category, script or property", name)
+				}
+				tables = append(tables, table)
+				i = end
+			default:
+				return "", nil, fmt.Errorf(`the escape "\%c" is invalid. Only "\\", "\-" and "\p{Name}" are accepted`, runes[i])
+			}
+			continue
+		}
+
+		// a range of characters, as long as the "-" is neither the first nor the last character of the set
+		if i+2 < len(runes) && runes[i+1] == '-' {
+			if runes[i+2] < char {
+				return "", nil, fmt.Errorf("the range of characters '%c-%c' is out of order", char, runes[i+2])
+			}
+			ranges = append(ranges, unicode.RangeTable{R32: []unicode.Range32{{Lo: uint32(char), Hi: uint32(runes[i+2]), Stride: 1}}})
+			i += 2
+			continue
+		}
+		chars = append(chars, char)
+	}
+
+	for i := range ranges {
+		tables = append(tables, &ranges[i])
+	}
+	return string(chars), tables, nil
+}
+
+// returns the Unicode category (e.g. "Lu"), script (e.g. "Latin") or property (e.g. "White_Space") with the given name
+func unicodeTable(name string) (*unicode.RangeTable, bool) {
+	for _, tables := range []map[string]*unicode.RangeTable{unicode.Categories, unicode.Scripts, unicode.Properties} {
+		if table, ok := tables[name]; ok {
+			return table, true
+		}
+	}
+	return nil, false
+}
+
+// maximum number of distinct characters returned by findChars, which bounds the length of the detail
+const maxFoundChars = 32
+
+// returns the first maxFoundChars distinct characters of the password that satisfy the predicate, in the
+// order they appear, and the number of times the characters that satisfy it appear in the password
+func findChars(password string, predicate func(rune) bool) (string, int) {
+	var found []rune
+	seen := map[rune]bool{}
+	count := 0
+	for _, char := range password {
+		if !predicate(char) {
+			continue
+		}
+		count++
+		if len(found) < maxFoundChars && !seen[char] {
+			seen[char] = true
+			found = append(found, char)
+		}
+	}
+	return string(found), count
+}
+
+// charsetRule is the Rule implementation of the forbiddenChars and allowedCharset rules, whose parameter is a
+// set of characters. The forbiddenChars rule rejects the passwords with any character of the set, and the
+// allowedCharset rule rejects the passwords with any character outside of the set. The value of the rules
+// is ignored.
+type charsetRule struct {
+	// tells if the set of characters is the set of allowed characters, instead of forbidden ones
+	allowed bool
+}
+
+// Check looks for the characters of the password that are not accepted. They are listed in the detail of the
+// result, so that the user knows what to remove.
+func (r charsetRule) Check(password string, config RuleConfig, opts Options) Result {
+	result := Result{Rule: config.Rule, Required: config.Value}
+	inSet, err := parseCharset(config.Param)
+	if err != nil {
+		result.Message = fmt.Sprintf("the password could not be checked: %v", err)
+		return result
+	}
+
+	rejected := inSet
+	what := "forbidden characters"
+	if r.allowed {
+		rejected = func(char rune) bool { return !inSet(char) }
+		what = "characters outside of the allowed set"
+	}
+
+	found, count := findChars(password, rejected)
+	result.Actual = count
+	result.Passed = count == 0
+	result.Message = fmt.Sprintf("the password has %d %s", count, what)
+	result.Detail = found
+	return result
+}
+
+// CheckConfig verifies that the parameter of the rule is a valid set of characters.
+func (r charsetRule) CheckConfig(config RuleConfig) error {
+	if config.Param == "" {
+		return fmt.Errorf("the rule '%s' requires a set of characters as its parameter", config.Rule)
+	}
+	if _, err := parseCharset(config.Param); err != nil {
+		return fmt.Errorf("the parameter '%s' of the rule '%s' is invalid: %w", config.Param, config.Rule, err)
+	}
+	return nil
+}
+
+// AcceptedChars returns the predicate that tells if a character is accepted by every forbiddenChars and
+// allowedCharset rule among the given rules, or nil if there is no such rule. The rules with an invalid set
+// of characters are ignored, since they are rejected by CheckConfig.
+func AcceptedChars(rules []RuleConfig) func(rune) bool {
+	var accepted []func(rune) bool
+	for _, config := range rules {
+		rule, _ := Lookup(config.Rule)
+		charset, ok := rule.(charsetRule)
+		if !ok {
+			continue
+		}
+		inSet, err := parseCharset(config.Param)
+		if err != nil {
+			continue
+		}
+		if charset.allowed {
+			accepted = append(accepted, inSet)
+		} else {
+			accepted = append(accepted, func(char rune) bool { return !inSet(char) })
+		}
+	}
+
+	if accepted == nil {
+		return nil
+	}
+	return func(char rune) bool {
+		for _, predicate := range accepted {
+			if !predicate(char) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// the tables of the Unicode classes that the classes of characters are made of in Unicode mode (see
+// charClassPredicate and specialCharsPredicate). In ASCII mode, the classes are made of ASCII characters.
+var classTables = []*unicode.RangeTable{unicode.Upper, unicode.Lower, unicode.Digit, unicode.Letter, unicode.Punct, unicode.Symbol}
+
+// adds to the boundaries the first character of each range of the table and the character after its last one,
+// every character of a range with a stride being a range of its own
+func addTableBoundaries(boundaries map[rune]bool, table *unicode.RangeTable) {
+	add := func(lo uint32, hi uint32, stride uint32) {
+		if stride != 1 {
+			for char := lo; char <= hi; char += stride {
+				boundaries[rune(char)], boundaries[rune(char)+1] = true, true
+			}
+			return
+		}
+		boundaries[rune(lo)], boundaries[rune(hi)+1] = true, true
+	}
+	for _, r := range table.R16 {
+		add(uint32(r.Lo), uint32(r.Hi), uint32(r.Stride))
+	}
+	for _, r := range table.R32 {
+		add(r.Lo, r.Hi, r.Stride)
+	}
+}
+
+// CharBoundaries returns, in order, the characters at which the classes of characters and the sets of
+// characters given as parameters of the rules begin or end, from the character 0. Any predicate made of
+// AcceptedChars, CharClass and their negations has the same value for every character from a boundary to the
+// next one, so some character satisfies the predicate if and only if one of the boundaries does, which can be
+// checked without going through every character.
+func CharBoundaries(rules []RuleConfig) []rune {
+	boundaries := map[rune]bool{0: true, 0xD800: true, 0xE000: true} // the surrogates are not characters
+	// every ASCII character, of which the classes of ASCII mode and the predefined sets of special characters
+	// are made
+	for char := rune(1); char <= utf8.RuneSelf; char++ {
+		boundaries[char] = true
+	}
+	for _, table := range classTables {
+		addTableBoundaries(boundaries, table)
+	}
+	for _, config := range rules {
+		for _, char := range config.Param {
+			boundaries[char], boundaries[char+1] = true, true
+		}
+		rule, _ := Lookup(config.Rule)
+		if _, ok := rule.(charsetRule); !ok {
+			continue
+		}
+		if _, tables, err := parseCharsetTables(config.Param); err == nil {
+			for _, table := range tables {
+				addTableBoundaries(boundaries, table)
+			}
+		}
+	}
+
+	sorted := make([]rune, 0, len(boundaries))
+	for char := range boundaries {
+		if char <= unicode.MaxRune {
+			sorted = append(sorted, char)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
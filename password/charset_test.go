@@ -0,0 +1,138 @@
+// unit tests to the rules about forbidden and allowed characters
+
+package password
+
+import (
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests the parsing of a set of characters
+func TestParseCharset(t *testing.T) {
+	tests := []struct {
+		set string
+		in  string
+		out string
+	}{
+		{set: `"' `, in: `"' `, out: "a1"},
+		{set: "a-z0-9", in: "az09m", out: "A!-"},
+		{set: `-a\-`, in: "-a", out: "b"},
+		{set: `\\\p{Lu}`, in: `\AÇ`, out: "a1"},
+		{set: `\p{Cc}\p{White_Space}`, in: "\t\n \x00", out: "a!"},
+		{set: `\p{Greek}`, in: "αΩ", out: "aЖ"},
+	}
+
+	for _, test := range tests {
+		inSet, err := parseCharset(test.set)
+		require.Nil(t, err, "the set '%s' should be valid", test.set)
+		for _, char := range test.in {
+			assert.True(t, inSet(char), "'%c' should be in the set '%s'", char, test.set)
+		}
+		for _, char := range test.out {
+			assert.False(t, inSet(char), "'%c' should not be in the set '%s'", char, test.set)
+		}
+	}
+
+	for _, set := range []string{`abc\`, `\p{Lu`, `\pLu`, `\p{Nope}`, `\d`, "z-a"} {
+		_, err := parseCharset(set)
+		assert.NotNil(t, err, "the set '%s' should be invalid", set)
+	}
+}
+
+// Tests the forbiddenChars rule
+func TestForbiddenChars(t *testing.T) {
+	config := RuleConfig{Rule: "forbiddenChars", Param: `"' \p{Cc}`}
+
+	assert.Equal(t, Result{
+		Rule:    "forbiddenChars",
+		Actual:  5,
+		Passed:  false,
+		Message: "the password has 5 forbidden characters",
+		Detail:  `' "`,
+	}, charsetRule{}.Check(`it's a "pass"`, config, Options{}))
+	assert.True(t, charsetRule{}.Check("x7#Qm!2vLp9@", config, Options{}).Passed)
+}
+
+// Tests the allowedCharset rule
+func TestAllowedCharset(t *testing.T) {
+	config := RuleConfig{Rule: "allowedCharset", Param: `a-zA-Z0-9!@#$%`}
+
+	result := charsetRule{allowed: true}.Check("Senha ção!!", config, Options{})
+	assert.False(t, result.Passed)
+	assert.Equal(t, 3, result.Actual)
+	assert.Equal(t, " çã", result.Detail)
+	assert.Equal(t, "the password has 3 characters outside of the allowed set", result.Message)
+
+	assert.True(t, charsetRule{allowed: true}.Check("Senha123!", config, Options{}).Passed)
+
+	// a password with many distinct characters outside of the set has a bounded detail
+	var cjk []rune
+	for char := rune(0x4E00); char < 0x4E00+20000; char++ {
+		cjk = append(cjk, char)
+	}
+	result = charsetRule{allowed: true}.Check(string(cjk), config, Options{})
+	assert.Equal(t, 20000, result.Actual)
+	assert.Equal(t, string(cjk[:maxFoundChars]), result.Detail)
+
+	assert.Nil(t, CheckConfig(RuleConfig{Rule: "allowedCharset", Param: `\p{L}\p{N}`}))
+	assert.NotNil(t, CheckConfig(RuleConfig{Rule: "allowedCharset"}), "the set of characters should be required")
+	assert.NotNil(t, CheckConfig(RuleConfig{Rule: "forbiddenChars", Param: `\p{Nope}`}))
+}
+
+// Tests the characters accepted by the forbiddenChars and allowedCharset rules together
+func TestAcceptedChars(t *testing.T) {
+	assert.Nil(t, AcceptedChars([]RuleConfig{{Rule: "minSize", Value: 8}}), "there should be no predicate without charset rules")
+
+	accepted := AcceptedChars([]RuleConfig{
+		{Rule: "allowedCharset", Param: "a-z0-9"},
+		{Rule: "forbiddenChars", Param: "0"},
+		{Rule: "forbiddenChars", Param: `\q`},
+	})
+	assert.True(t, accepted('a'))
+	assert.True(t, accepted('9'))
+	assert.False(t, accepted('0'))
+	assert.False(t, accepted('A'))
+}
+
+// Tests that checking the boundaries finds the same characters as checking every character
+func TestCharBoundaries(t *testing.T) {
+	// tells if some character satisfies the predicate, checking the given characters
+	anyOf := func(chars []rune, predicate func(rune) bool) bool {
+		for _, char := range chars {
+			if (char < 0xD800 || char > 0xDFFF) && predicate(char) {
+				return true
+			}
+		}
+		return false
+	}
+	var every []rune
+	for char := rune(0); char <= unicode.MaxRune; char++ {
+		every = append(every, char)
+	}
+
+	rule_sets := [][]RuleConfig{
+		{{Rule: "allowedCharset", Param: "a-z"}},
+		{{Rule: "allowedCharset", Param: `\p{Greek}0-4#`}, {Rule: "forbiddenChars", Param: `\p{Ll}`}},
+		{{Rule: "forbiddenChars", Param: "\u0000-\U0010FFFF"}},
+		{{Rule: "allowedCharset", Param: "\u00C0-\u00FF"}, {Rule: "minSpecialChars", Param: "×÷"}},
+	}
+	for _, rules := range rule_sets {
+		boundaries := CharBoundaries(rules)
+		accepted := AcceptedChars(rules)
+		for _, opts := range []Options{{}, {Unicode: true}} {
+			for _, class := range []string{"upper", "lower", "digit", "letter", "special"} {
+				param := ""
+				if class == "special" && len(rules) > 1 {
+					param = rules[1].Param
+				}
+				in_class := CharClass(class, param, opts)
+				predicate := func(char rune) bool { return accepted(char) && in_class(char) }
+				assert.Equal(t, anyOf(every, predicate), anyOf(boundaries, predicate),
+					"the boundaries of %v should find a character of the class '%s' (unicode: %v) as every character does", rules, class, opts.Unicode)
+			}
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package password
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// default length of the keyboard walks rejected by the noKeyboardPattern rule, used when its value is 0
+const defaultKeyboardWalk = 4
+
+// keyboardRow is a row of keys of a keyboard layout. The keys are given in the order they appear in
+// the row, without and with shift, and the offset is the horizontal position of the first key, in
+// keys, which reproduces the stagger of the rows of a physical keyboard.
+type keyboardRow struct {
+	keys    string
+	shifted string
+	offset  float64
+}
+
+// keyboardLayout is a keyboard layout, identified by the name accepted as the parameter of the
+// noKeyboardPattern rule.
+type keyboardLayout struct {
+	name string
+	rows []keyboardRow
+}
+
+// position of a key in a keyboard layout
+type keyPosition struct {
+	row int
+	x   float64
+}
+
+// keyboard layouts checked by the noKeyboardPattern rule. Only the main block of keys is considered.
+var keyboardLayouts = []keyboardLayout{
+	{
+		name: "qwerty",
+		rows: []keyboardRow{
+			{keys: "`1234567890-=", shifted: "~!@#$%^&*()_+", offset: 0},
+			{keys: `qwertyuiop[]\`, shifted: "QWERTYUIOP{}|", offset: 1.5},
+			{keys: "asdfghjkl;'", shifted: `ASDFGHJKL:"`, offset: 1.75},
+			{keys: "zxcvbnm,./", shifted: "ZXCVBNM<>?", offset: 2.25},
+		},
+	},
+	{
+		name: "abnt2",
+		rows: []keyboardRow{
+			{keys: "'1234567890-=", shifted: `"!@#$%¨&*()_+`, offset: 0},
+			{keys: "qwertyuiop´[", shifted: "QWERTYUIOP`{", offset: 1.5},
+			{keys: "asdfghjklç~]", shifted: "ASDFGHJKLÇ^}", offset: 1.75},
+			{keys: `\zxcvbnm,.;/`, shifted: "|ZXCVBNM<>:?", offset: 1.25},
+		},
+	},
+	{
+		name: "azerty",
+		rows: []keyboardRow{
+			{keys: `²&é"'(-è_çà)=`, shifted: "²1234567890°+", offset: 0},
+			{keys: "azertyuiop^$", shifted: "AZERTYUIOP¨£", offset: 1.5},
+			{keys: "qsdfghjklmù*", shifted: "QSDFGHJKLM%µ", offset: 1.75},
+			{keys: "<wxcvbn,;:!", shifted: ">WXCVBN?./§", offset: 1.25},
+		},
+	},
+}
+
+// positions of the characters of each keyboard layout, indexed by the name of the layout
+var keyPositions = buildKeyPositions(keyboardLayouts)
+
+// maps each character of the keyboard layouts to the position of its key. A character typed with
+// shift is at the same position as the character typed without it.
+func buildKeyPositions(layouts []keyboardLayout) map[string]map[rune]keyPosition {
+	positions := make(map[string]map[rune]keyPosition, len(layouts))
+
+	for _, layout := range layouts {
+		layoutPositions := map[rune]keyPosition{}
+		for row_index, row := range layout.rows {
+			if utf8.RuneCountInString(row.keys) != utf8.RuneCountInString(row.shifted) {
+				panic(fmt.Sprintf("password: row %d of the keyboard layout '%s' has keys without shift", row_index, layout.name))
+			}
+			shifted := []rune(row.shifted)
+			for i, key := range []rune(row.keys) {
+				position := keyPosition{row: row_index, x: row.offset + float64(i)}
+				layoutPositions[key] = position
+				layoutPositions[shifted[i]] = position
+			}
+		}
+		positions[layout.name] = layoutPositions
+	}
+	return positions
+}
+
+// checks if two keys are next to each other: side by side in the same row, or touching each other
+// in adjacent rows (e.g. "q", "w" and "a" are next to each other in QWERTY, but "q" and "s" are not)
+func isAdjacentKey(a keyPosition, b keyPosition) bool {
+	dx := math.Abs(a.x - b.x)
+	switch a.row - b.row {
+	case 0:
+		return dx == 1
+	case 1, -1:
+		return dx < 1
+	}
+	return false
+}
+
+// returns the length, in keys, of the longest walk in a string through keys next to each other in the
+// given keyboard layout (e.g. "qwerty", "asdf" and "1qaz" are walks in QWERTY)
+func longestKeyboardWalk(password string, layout string) int {
+	positions := keyPositions[layout]
+	var prev keyPosition
+	prevFound := false
+	longest, walk := 0, 0
+
+	for _, char := range password {
+		position, found := positions[char]
+		switch {
+		case !found:
+			walk = 0
+		case prevFound && isAdjacentKey(prev, position):
+			walk++
+		default:
+			walk = 1
+		}
+		if walk > longest {
+			longest = walk
+		}
+		prev, prevFound = position, found
+	}
+	return longest
+}
+
+// returns the length of the longest walk on a keyboard found in the password, and the layout in which it
+// was found. An empty layout name selects every layout.
+func findKeyboardWalk(password string, layout string) (int, string) {
+	longest, longestLayout := 0, ""
+	for _, keyboard := range keyboardLayouts {
+		if layout != "" && keyboard.name != layout {
+			continue
+		}
+		if walk := longestKeyboardWalk(password, keyboard.name); walk > longest {
+			longest, longestLayout = walk, keyboard.name
+		}
+	}
+	return longest, longestLayout
+}
+
+// returns the length of the keyboard walks rejected by the noKeyboardPattern rule
+func rejectedWalk(value int) int {
+	if value == 0 {
+		return defaultKeyboardWalk
+	}
+	return value
+}
+
+// keyboardPatternRule is the Rule implementation of the noKeyboardPattern rule, which rejects passwords
+// with walks through keys next to each other (e.g. "qwerty", "asdfgh", "1qaz2wsx"). The value of the
+// rule is the length of the shortest walk rejected, and the parameter can restrict the check to a
+// single keyboard layout.
+type keyboardPatternRule struct{}
+
+// Check looks for keyboard walks in the password.
+func (keyboardPatternRule) Check(password string, config RuleConfig, opts Options) Result {
+	walk, layout := findKeyboardWalk(password, config.Param)
+	rejected := rejectedWalk(config.Value)
+
+	message := fmt.Sprintf("the password has no walk of %d or more keys next to each other on the keyboard", rejected)
+	if walk >= rejected {
+		message = fmt.Sprintf("the password has a walk of %d keys next to each other on the %s keyboard, at most %d allowed",
+			walk, strings.ToUpper(layout), rejected-1)
+	}
+	return Result{
+		Rule:     config.Rule,
+		Required: config.Value,
+		Actual:   walk,
+		Passed:   walk < rejected,
+		Message:  message,
+	}
+}
+
+// CheckConfig verifies that the parameter of the rule, when given, is the name of a known keyboard layout.
+func (keyboardPatternRule) CheckConfig(config RuleConfig) error {
+	if _, ok := keyPositions[config.Param]; config.Param != "" && !ok {
+		names := make([]string, 0, len(keyboardLayouts))
+		for _, layout := range keyboardLayouts {
+			names = append(names, layout.name)
+		}
+		return fmt.Errorf("the parameter '%s' of the rule '%s' is invalid. List of accepted keyboard layouts: %v",
+			config.Param, config.Rule, names)
+	}
+	return nil
+}
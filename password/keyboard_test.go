@@ -0,0 +1,86 @@
+// unit tests to the detection of keyboard patterns
+
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests the adjacency of keys in the QWERTY layout
+func TestIsAdjacentKey(t *testing.T) {
+	positions := keyPositions["qwerty"]
+	tests := []struct {
+		a, b        rune
+		want_output bool
+	}{
+		{a: 'q', b: 'w', want_output: true},
+		{a: 'q', b: 'a', want_output: true},
+		{a: 'q', b: '1', want_output: true},
+		{a: 'q', b: '2', want_output: true},
+		{a: 'a', b: 'z', want_output: true},
+		{a: 'Q', b: 'w', want_output: true},
+		{a: 'q', b: 's', want_output: false},
+		{a: 'q', b: 'e', want_output: false},
+		{a: 'a', b: 'x', want_output: false},
+		{a: 'z', b: '2', want_output: false},
+	}
+
+	for _, test := range tests {
+		result := isAdjacentKey(positions[test.a], positions[test.b])
+		assert.Equal(t, test.want_output, result,
+			"it was expected that the adjacency of the keys '%c' and '%c' would be %t", test.a, test.b, test.want_output)
+	}
+}
+
+// Tests the length of the longest keyboard walk in a string
+func TestFindKeyboardWalk(t *testing.T) {
+	tests := []struct {
+		password_input string
+		layout         string
+		want_walk      int
+		want_layout    string
+	}{
+		{password_input: "qwerty", want_walk: 6, want_layout: "qwerty"},
+		{password_input: "asdfgh", want_walk: 6, want_layout: "qwerty"},
+		{password_input: "1qaz2wsx", want_walk: 4, want_layout: "qwerty"},
+		{password_input: "QWErty!", want_walk: 6, want_layout: "qwerty"},
+		{password_input: "azerty", layout: "qwerty", want_walk: 4, want_layout: "qwerty"},
+		{password_input: "azerty", want_walk: 6, want_layout: "azerty"},
+		{password_input: "jklç~]", want_walk: 6, want_layout: "abnt2"},
+		{password_input: "x7#Qm!2vLp9@", want_walk: 2, want_layout: "qwerty"}, // only "!2"
+		{password_input: "", want_walk: 0, want_layout: ""},
+	}
+
+	for _, test := range tests {
+		walk, layout := findKeyboardWalk(test.password_input, test.layout)
+		assert.Equal(t, test.want_walk, walk,
+			"it was expected that the longest keyboard walk in '%s' would have %v keys", test.password_input, test.want_walk)
+		assert.Equal(t, test.want_layout, layout,
+			"it was expected that the longest keyboard walk in '%s' would be in the layout %s", test.password_input, test.want_layout)
+	}
+}
+
+// Tests the noKeyboardPattern rule
+func TestNoKeyboardPattern(t *testing.T) {
+	results := CheckPassword("1qaz2wsx", []RuleConfig{
+		{Rule: "noKeyboardPattern", Value: 0},
+		{Rule: "noKeyboardPattern", Value: 5},
+		{Rule: "noKeyboardPattern", Value: 0, Param: "abnt2"},
+	}, Options{})
+
+	assert.Equal(t, Result{
+		Rule:     "noKeyboardPattern",
+		Required: 0,
+		Actual:   4,
+		Passed:   false,
+		Message:  "the password has a walk of 4 keys next to each other on the QWERTY keyboard, at most 3 allowed",
+	}, results[0])
+	assert.True(t, results[1].Passed)
+	assert.False(t, results[2].Passed)
+	assert.Equal(t, "the password has a walk of 4 keys next to each other on the ABNT2 keyboard, at most 3 allowed", results[2].Message)
+
+	assert.Nil(t, CheckConfig(RuleConfig{Rule: "noKeyboardPattern", Param: "abnt2"}))
+	assert.NotNil(t, CheckConfig(RuleConfig{Rule: "noKeyboardPattern", Param: "dvorak"}))
+}
@@ -1,11 +1,27 @@
 package password
 
 import (
-	"graphpass/utils"
+	"fmt"
 	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+// Result is the outcome of a single rule applied to a password. Besides telling if the rule was
+// satisfied, it carries the value required by the rule and the value measured in the password, so
+// that front-ends can show the user how far the password is from meeting each rule.
+type Result struct {
+	Rule     string
+	Required int
+	Actual   int
+	Passed   bool
+	Message  string
+	// Detail optionally carries what made the rule fail (e.g. the common password matched), to help the
+	// user understand the result
+	Detail string
+}
+
 // counts the number of uppercase characters in a string
 func countUppercaseChars(password string) int {
 	r := regexp.MustCompile("[A-Z]")
@@ -24,12 +40,78 @@ func countDigits(password string) int {
 	return len(r.FindAllString(password, -1))
 }
 
-// counts the number of special characters in a string
+// counts the number of special characters of the default set in a string
 func countSpecialChars(password string) int {
-	r := regexp.MustCompile(`[!@#$%^&*()-+\/{}[]`)
-	return len(r.FindAllString(password, -1))
+	return countRunes(password, inSet(defaultSpecialChars))
+}
+
+// counts the number of characters of a string that satisfy the given predicate
+func countRunes(password string, predicate func(rune) bool) int {
+	count := 0
+	for _, char := range password {
+		if predicate(char) {
+			count++
+		}
+	}
+	return count
+}
+
+// counts the number of uppercase characters in a string, in any script (e.g. "Ç")
+func countUnicodeUppercaseChars(password string) int {
+	return countRunes(password, unicode.IsUpper)
+}
+
+// counts the number of lowercase characters in a string, in any script (e.g. "ã")
+func countUnicodeLowerCaseChars(password string) int {
+	return countRunes(password, unicode.IsLower)
+}
+
+// counts the number of decimal digits in a string, in any script (e.g. "٣")
+func countUnicodeDigits(password string) int {
+	return countRunes(password, unicode.IsDigit)
 }
 
+// counts the number of punctuation and symbol characters in a string (e.g. "!", "€", "。")
+func countUnicodeSpecialChars(password string) int {
+	return countRunes(password, specialCharsPredicate("", Options{Unicode: true}))
+}
+
+// counts the number of distinct characters in a string, so that a character repeated many times counts
+// once (e.g. "aAaAaA1!1!" has 4 distinct characters). Uppercase and lowercase letters are distinct.
+func countUniqueChars(password string) int {
+	seen := map[rune]bool{}
+	for _, char := range password {
+		seen[char] = true
+	}
+	return len(seen)
+}
+
+// number of classes of characters counted by the minCharClasses rule: uppercase letters, lowercase letters,
+// digits and special characters
+const charClasses = 4
+
+// builds a function that counts how many of the classes of characters measured by the given functions are
+// present in a string
+func countClasses(counters ...func(string) int) func(string) int {
+	return func(password string) int {
+		classes := 0
+		for _, count := range counters {
+			if count(password) > 0 {
+				classes++
+			}
+		}
+		return classes
+	}
+}
+
+// counts how many classes of characters (uppercase letters, lowercase letters, digits and special
+// characters) are present in a string (e.g. "Senha123" has 3 classes)
+var countCharClasses = countClasses(countUppercaseChars, countLowerCaseChars, countDigits, countSpecialChars)
+
+// counts how many classes of characters are present in a string, in any script
+var countUnicodeCharClasses = countClasses(countUnicodeUppercaseChars, countUnicodeLowerCaseChars,
+	countUnicodeDigits, countUnicodeSpecialChars)
+
 // Check if a string has sequential repeating characters
 // returns true if there is repetition, false if there is no repetition
 func isRepeat(password string) bool {
@@ -45,6 +127,91 @@ func isRepeat(password string) bool {
 	return false
 }
 
+// returns the length of the longest run of sequential repeated characters in a string
+// (e.g. "bookkeeper" has runs of 2 characters, "aaab" has a run of 3 characters)
+func longestRun(password string) int {
+	var prevChar string // stores the previously visited character
+	longest, run := 0, 0
+
+	for _, char := range strings.Split(password, "") {
+		if prevChar == char {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		prevChar = char
+	}
+	return longest
+}
+
+// returns the length of the longest run of repeated characters allowed by the noRepeted rule. The values
+// 0 and 1 allow no repetition at all, which was the behaviour of the rule before it accepted a value.
+func allowedRun(value int) int {
+	if value < 1 {
+		return 1
+	}
+	return value
+}
+
+// returns the length of the longest ascending or descending sequence of consecutive characters in a string
+// (e.g. "abcd" and "4321" are sequences of 4 characters). Letters are compared case-insensitively, so
+// "aBcD" is also a sequence.
+func longestSequence(password string) int {
+	var prevChar rune
+	var prevStep rune // difference between the code points of the previous character and the one before it
+	longest, run := 0, 0
+
+	for _, char := range password {
+		char = unicode.ToLower(char)
+		step := char - prevChar
+
+		switch {
+		case run > 0 && (step == 1 || step == -1) && (run == 1 || step == prevStep):
+			run++
+		case run > 0 && (step == 1 || step == -1):
+			run = 2 // the direction changed, so the previous character starts a new sequence
+		default:
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		prevStep = step
+		prevChar = char
+	}
+	return longest
+}
+
+// returns the length of the longest sequence of consecutive characters allowed by the noSequential rule.
+// The value 0 selects the default, which allows pairs (e.g. "ab") but no longer sequence.
+func allowedSequence(value int) int {
+	if value == 0 {
+		return 2
+	}
+	return value
+}
+
+// the functions that return the value a rule is applied with, for the rules whose value 0 selects a default
+var appliedValues = map[string]func(int) int{
+	"noRepeted":         allowedRun,
+	"noSequential":      allowedSequence,
+	"noKeyboardPattern": rejectedWalk,
+	"noUserInfo":        userInfoLength,
+	"notBreached":       breachThreshold,
+}
+
+// AppliedValue returns the value the rule is applied with, which is the default of the rule when its value
+// selects it (e.g. 4 for the value 0 of noKeyboardPattern), and the given value otherwise.
+func AppliedValue(rule string, value int) int {
+	if applied, ok := appliedValues[rule]; ok {
+		return applied(value)
+	}
+	return value
+}
+
 // checks if the password has the minimum length stipulated by the user
 func minSize(password string, threshold int) bool {
 	return len(password) >= threshold
@@ -70,42 +237,237 @@ func minSpecialChars(password string, threshold int) bool {
 	return countSpecialChars(password) >= threshold
 }
 
-// This function checks for sequential repetition in the password. It returns true if there
-// are no repeated characters in the password, and false otherwise. In other words, true
-// indicates a valid password (without sequential repetition) and false indicates an invalid password.
+// This function checks for sequential repetition in the password. The value is the maximum length allowed
+// for a run of repeated characters (e.g. with value 2 "book" is valid, but "boook" is not), and the values
+// 0 and 1 forbid any repetition. It returns true if there is no run longer than allowed in the password, and
+// false otherwise. In other words, true indicates a valid password and false indicates an invalid password.
 func noRepeted(password string, value int) bool {
-	return !isRepeat(password)
+	if allowedRun(value) == 1 {
+		return !isRepeat(password)
+	}
+	return longestRun(password) <= value
 }
 
-// The ValidPassword function verifies whether a given password adheres to all rules specified by the user.
-// It returns a boolean indicating whether the password is valid or not, and a list of error messages detailing
-// any rules that the password failed to meet.
-func ValidPassword(password string, rules []utils.Rule) (bool, []string) {
-	noMatched := make([]string, 0)
-	var validPassword bool = true
+// This function checks for sequences of consecutive characters in the password (e.g. "abc", "123", "cba").
+// It returns true if there is no sequence longer than the value, and false otherwise.
+func noSequential(password string, value int) bool {
+	return longestSequence(password) <= allowedSequence(value)
+}
+
+// builds a function that checks if a string has at least the amount measured by the given function
+func atLeast(measure func(string) int) func(string, int) bool {
+	return func(password string, threshold int) bool {
+		return measure(password) >= threshold
+	}
+}
+
+// checkedRule is the Rule implementation of the built-in rules. It groups the function that checks if the
+// password satisfies the rule, the function that measures in the password the quantity the rule is about
+// and the function that describes the result to the user.
+type checkedRule struct {
+	check   func(string, int) bool
+	measure func(string) int
+	message func(actual int, required int) string
+	// variant of the rule used in Unicode mode, nil if the rule behaves the same way in both modes
+	unicode *checkedRule
+}
+
+// Check applies the built-in rule to the password.
+func (r checkedRule) Check(password string, config RuleConfig, opts Options) Result {
+	if opts.Unicode && r.unicode != nil {
+		r = *r.unicode
+	}
+	actual := r.measure(password)
 
-	// The user can choose from a set of predefined password rules. By the time this data reaches this
-	// function, it has already been validated to ensure that the chosen rules are among the allowed rules.
-	// Thus, to cater to different set of rules, this implementation utilizes dynamic function execution
-	// technique. To facilitate this, a map structure is used, which indexes the functions by their names.
-	mappedFunc := map[string]func(string, int) bool{
-		"minSize":         minSize,
-		"minUppercase":    minUpperCase,
-		"minLowercase":    minLowerCase,
-		"minDigit":        minDigit,
-		"minSpecialChars": minSpecialChars,
-		"noRepeted":       noRepeted,
+	return Result{
+		Rule:     config.Rule,
+		Required: config.Value,
+		Actual:   actual,
+		Passed:   r.check(password, config.Value),
+		Message:  r.message(actual, config.Value),
 	}
+}
+
+// builds the message of the rules that set a minimum amount of something (e.g. digits) in the password
+func minMessage(what string) func(int, int) string {
+	return func(actual int, required int) string {
+		return fmt.Sprintf("the password has %d %s, at least %d required", actual, what, required)
+	}
+}
+
+// builds a function that checks if a string has at most the amount measured by the given function
+func atMost(measure func(string) int) func(string, int) bool {
+	return func(password string, threshold int) bool {
+		return measure(password) <= threshold
+	}
+}
+
+// builds the message of the rules that set a maximum amount of something (e.g. digits) in the password
+func maxMessage(what string) func(int, int) string {
+	return func(actual int, allowed int) string {
+		return fmt.Sprintf("the password has %d %s, at most %d allowed", actual, what, allowed)
+	}
+}
+
+// builds a rule that sets a maximum amount of something in the password, measured by the given functions
+// in ASCII and Unicode modes
+func maxRule(measure func(string) int, unicodeMeasure func(string) int, what string) checkedRule {
+	return checkedRule{
+		check:   atMost(measure),
+		measure: measure,
+		message: maxMessage(what),
+		unicode: &checkedRule{
+			check:   atMost(unicodeMeasure),
+			measure: unicodeMeasure,
+			message: maxMessage(what),
+		},
+	}
+}
+
+// builds the Unicode mode variant of a rule that sets a minimum amount of something in the password
+func unicodeMin(measure func(string) int, what string) *checkedRule {
+	return &checkedRule{
+		check:   atLeast(measure),
+		measure: measure,
+		message: minMessage(what),
+	}
+}
+
+// charClassesRule is the Rule implementation of the minCharClasses rule, a built-in rule that also limits its
+// value to the number of classes of characters, since no password could satisfy a higher value.
+type charClassesRule struct {
+	checkedRule
+}
+
+// CheckConfig verifies that the value of the rule is not above the number of classes of characters.
+func (r charClassesRule) CheckConfig(config RuleConfig) error {
+	if config.Value > charClasses {
+		return fmt.Errorf("the value %d of the rule '%s' is invalid. There are only %d classes of characters",
+			config.Value, config.Rule, charClasses)
+	}
+	if config.Param != "" {
+		return fmt.Errorf("the rule '%s' does not accept a parameter", config.Rule)
+	}
+	return nil
+}
+
+// registers the built-in rules
+func init() {
+	Register("minSize", checkedRule{
+		check:   minSize,
+		measure: func(password string) int { return len(password) },
+		message: minMessage("characters"),
+		unicode: unicodeMin(utf8.RuneCountInString, "characters"),
+	})
+	Register("minUppercase", checkedRule{
+		check:   minUpperCase,
+		measure: countUppercaseChars,
+		message: minMessage("uppercase letters"),
+		unicode: unicodeMin(countUnicodeUppercaseChars, "uppercase letters"),
+	})
+	Register("minLowercase", checkedRule{
+		check:   minLowerCase,
+		measure: countLowerCaseChars,
+		message: minMessage("lowercase letters"),
+		unicode: unicodeMin(countUnicodeLowerCaseChars, "lowercase letters"),
+	})
+	Register("minDigit", checkedRule{
+		check:   minDigit,
+		measure: countDigits,
+		message: minMessage("digits"),
+		unicode: unicodeMin(countUnicodeDigits, "digits"),
+	})
+	Register("minSpecialChars", specialCharsRule{build: minSpecialCharsRule})
+	Register("noRepeted", checkedRule{
+		check:   noRepeted,
+		measure: longestRun,
+		message: func(actual int, value int) string {
+			return fmt.Sprintf("the longest run of repeated characters in the password has %d characters, at most %d allowed",
+				actual, allowedRun(value))
+		},
+	})
+	Register("maxSize", maxRule(func(password string) int { return len(password) }, utf8.RuneCountInString, "characters"))
+	Register("maxUppercase", maxRule(countUppercaseChars, countUnicodeUppercaseChars, "uppercase letters"))
+	Register("maxLowercase", maxRule(countLowerCaseChars, countUnicodeLowerCaseChars, "lowercase letters"))
+	Register("maxDigit", maxRule(countDigits, countUnicodeDigits, "digits"))
+	Register("maxSpecialChars", specialCharsRule{build: maxSpecialCharsRule})
+	Register("noSequential", checkedRule{
+		check:   noSequential,
+		measure: longestSequence,
+		message: func(actual int, value int) string {
+			return fmt.Sprintf("the longest sequence of consecutive characters in the password has %d characters, at most %d allowed",
+				actual, allowedSequence(value))
+		},
+	})
+	Register("noKeyboardPattern", keyboardPatternRule{})
+	Register("notCommon", NotCommon(nil))
+	Register("notBreached", NotBreached(nil))
+	Register("noUserInfo", userInfoRule{})
+	Register("minEditDistance", editDistanceRule{})
+	Register("minUniqueChars", checkedRule{
+		check:   atLeast(countUniqueChars),
+		measure: countUniqueChars,
+		message: minMessage("distinct characters"),
+	})
+	Register("minCharClasses", charClassesRule{checkedRule{
+		check:   atLeast(countCharClasses),
+		measure: countCharClasses,
+		message: minMessage("classes of characters"),
+		unicode: unicodeMin(countUnicodeCharClasses, "classes of characters"),
+	}})
+	Register("forbiddenChars", charsetRule{})
+	Register("allowedCharset", charsetRule{allowed: true})
+	Register("matchesRegex", regexRule{})
+	Register("notMatchesRegex", regexRule{negated: true})
+	Register("firstCharClass", positionRule{})
+	Register("lastCharClass", positionRule{last: true})
+	Register("firstCharNotClass", positionRule{negated: true})
+	Register("lastCharNotClass", positionRule{last: true, negated: true})
+}
+
+// The CheckPassword function applies each rule specified by the user to the given password and returns
+// one Result per rule, in the same order as the rules were received. Unlike ValidPassword, which only
+// tells which rules failed, the results also carry the measured and required values of each rule.
+// The options apply to every rule (e.g. to select the Unicode mode).
+func CheckPassword(password string, rules []RuleConfig, opts Options) []Result {
+	results := make([]Result, 0, len(rules))
 
 	for _, m := range rules {
-		rule := m.Rule
-		value := m.Value
+		// The user can choose any rule in the registry. By the time the rules reach this function, they
+		// have already been validated to ensure that the chosen rules are among the registered rules, so
+		// a missing rule can only happen when this function is called directly, without input checking.
+		rule, ok := Lookup(m.Rule)
+		if !ok {
+			results = append(results, Result{
+				Rule:     m.Rule,
+				Required: m.Value,
+				Message:  fmt.Sprintf("the rule '%s' is not registered", m.Rule),
+			})
+			continue
+		}
+		results = append(results, rule.Check(password, m, opts)) // run rule dinamically
+	}
+	return results
+}
+
+// The ValidPassword function verifies whether a given password adheres to all rules specified by the user.
+// It returns a boolean indicating whether the password is valid or not, and a list of error messages detailing
+// any rules that the password failed to meet. The rules are applied with the default options (ASCII mode).
+func ValidPassword(password string, rules []RuleConfig) (bool, []string) {
+	return Summarize(CheckPassword(password, rules, Options{}))
+}
+
+// Summarize reduces the results of CheckPassword to the overall verdict and the list of rules that
+// the password failed to meet.
+func Summarize(results []Result) (bool, []string) {
+	noMatched := make([]string, 0)
+	var validPassword bool = true
 
-		result := mappedFunc[rule](password, value) // run function dinamically
+	for _, result := range results {
 		// if the result is false, we know that the rule has not been matched
-		if !result {
+		if !result.Passed {
 			// put the no matched rule in a slice, to return to user
-			noMatched = append(noMatched, rule)
+			noMatched = append(noMatched, result.Rule)
 		}
 	}
 	// if the noMatched slice are empty the password is valid
@@ -3,7 +3,6 @@
 package password
 
 import (
-	"graphpass/utils"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -103,6 +102,85 @@ func TestCountSpecialChars(t *testing.T) {
 	}
 }
 
+// Tests the count of distinct characters
+func TestCountUniqueChars(t *testing.T) {
+	tests := []struct {
+		password_input string
+		want_output    int
+	}{
+		{password_input: "", want_output: 0},
+		{password_input: "aaaaaa", want_output: 1},
+		{password_input: "aAaAaA1!1!", want_output: 4},
+		{password_input: "senha@123", want_output: 9},
+		{password_input: "パスワードパ", want_output: 5},
+	}
+
+	for _, test := range tests {
+		result := countUniqueChars(test.password_input)
+		assert.Equal(t, test.want_output, result,
+			"Test of verification of password '%s' failed: it was expected that "+
+				"the number of distinct characters would be %v, but it is %v",
+			test.password_input, test.want_output, result,
+		)
+	}
+}
+
+// Tests the count of the classes of characters present in a string
+func TestCountCharClasses(t *testing.T) {
+	tests := []struct {
+		password_input string
+		unicode        bool
+		want_output    int
+	}{
+		{password_input: "", want_output: 0},
+		{password_input: "senha", want_output: 1},
+		{password_input: "Senha123", want_output: 3},
+		{password_input: "Senha@123", want_output: 4},
+		{password_input: "Çãozinho", want_output: 1},
+		{password_input: "Çãozinho", unicode: true, want_output: 2},
+	}
+
+	for _, test := range tests {
+		count := countCharClasses
+		if test.unicode {
+			count = countUnicodeCharClasses
+		}
+		result := count(test.password_input)
+		assert.Equal(t, test.want_output, result,
+			"Test of verification of password '%s' failed: it was expected that "+
+				"the number of classes of characters would be %v, but it is %v",
+			test.password_input, test.want_output, result,
+		)
+	}
+}
+
+// Tests the count of each class of characters in Unicode mode
+func TestCountUnicodeChars(t *testing.T) {
+	tests := []struct {
+		password_input string
+		want_upper     int
+		want_lower     int
+		want_digits    int
+		want_special   int
+	}{
+		{password_input: "ÇãoAb1!", want_upper: 2, want_lower: 3, want_digits: 1, want_special: 1},
+		{password_input: "パスワード١٢", want_upper: 0, want_lower: 0, want_digits: 2, want_special: 0},
+		{password_input: "Ñandú€。_", want_upper: 1, want_lower: 4, want_digits: 0, want_special: 3},
+		{password_input: "", want_upper: 0, want_lower: 0, want_digits: 0, want_special: 0},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.want_upper, countUnicodeUppercaseChars(test.password_input),
+			"wrong number of uppercase characters in '%s'", test.password_input)
+		assert.Equal(t, test.want_lower, countUnicodeLowerCaseChars(test.password_input),
+			"wrong number of lowercase characters in '%s'", test.password_input)
+		assert.Equal(t, test.want_digits, countUnicodeDigits(test.password_input),
+			"wrong number of digits in '%s'", test.password_input)
+		assert.Equal(t, test.want_special, countUnicodeSpecialChars(test.password_input),
+			"wrong number of special characters in '%s'", test.password_input)
+	}
+}
+
 // Tests if a string has sequential repeating characters
 func TestIsRepeat(t *testing.T) {
 	tests := []struct {
@@ -126,6 +204,56 @@ func TestIsRepeat(t *testing.T) {
 	}
 }
 
+// Tests the length of the longest run of sequential repeated characters in a string
+func TestLongestRun(t *testing.T) {
+	tests := []struct {
+		password_input string
+		want_output    int
+	}{
+		{password_input: "aaaAAaaBccD", want_output: 3},
+		{password_input: "bookkeeper", want_output: 2},
+		{password_input: "a2!612$", want_output: 1},
+		{password_input: "ããão", want_output: 3},
+		{password_input: "", want_output: 0},
+	}
+
+	for _, test := range tests {
+		result := longestRun(test.password_input)
+		assert.Equal(t, test.want_output, result,
+			"Test of verification of password '%s' failed: it was expected that "+
+				"the longest run of repeated characters would be %v, but it is %v",
+			test.password_input, test.want_output, result,
+		)
+	}
+}
+
+// Tests the length of the longest sequence of consecutive characters in a string
+func TestLongestSequence(t *testing.T) {
+	tests := []struct {
+		password_input string
+		want_output    int
+	}{
+		{password_input: "abcd", want_output: 4},
+		{password_input: "4321", want_output: 4},
+		{password_input: "aBcD", want_output: 4},
+		{password_input: "Abcd1234!", want_output: 4},
+		{password_input: "abcba", want_output: 3},
+		{password_input: "aba", want_output: 2},
+		{password_input: "a1b2c3", want_output: 1},
+		{password_input: "x", want_output: 1},
+		{password_input: "", want_output: 0},
+	}
+
+	for _, test := range tests {
+		result := longestSequence(test.password_input)
+		assert.Equal(t, test.want_output, result,
+			"Test of verification of password '%s' failed: it was expected that "+
+				"the longest sequence of consecutive characters would be %v, but it is %v",
+			test.password_input, test.want_output, result,
+		)
+	}
+}
+
 // Struct for defining a type for the inputs of tests that verify
 // if a string meets a certain minimum requirement
 type caseTestMinsFormat struct {
@@ -210,6 +338,10 @@ func TestNoRepeat(t *testing.T) {
 		{password_input: "aaaAAaaBccD", threshold: 0, want_output: false},
 		{password_input: "461a#@da616", threshold: 0, want_output: true},
 		{password_input: "TESTE", threshold: 0, want_output: true},
+		{password_input: "bookkeeper", threshold: 1, want_output: false},
+		{password_input: "bookkeeper", threshold: 2, want_output: true},
+		{password_input: "boookkeeper", threshold: 2, want_output: false},
+		{password_input: "boookkeeper", threshold: 3, want_output: true},
 	}
 	for _, test := range tests {
 		result := noRepeted(test.password_input, test.threshold)
@@ -222,18 +354,37 @@ func TestNoRepeat(t *testing.T) {
 	}
 }
 
+// Tests if a string has no sequence of consecutive characters longer than allowed
+func TestNoSequential(t *testing.T) {
+	tests := []caseTestMinsFormat{
+		{password_input: "Abcd1234!", threshold: 3, want_output: false},
+		{password_input: "Abcd1234!", threshold: 4, want_output: true},
+		{password_input: "x7#Qm!2vLp9@", threshold: 0, want_output: true},
+		{password_input: "senha123", threshold: 0, want_output: false},
+		{password_input: "senha12", threshold: 0, want_output: true},
+	}
+	for _, test := range tests {
+		result := noSequential(test.password_input, test.threshold)
+		assert.EqualValues(t, test.want_output, result,
+			"Test of verification of password '%s' failed: it was expected that "+
+				"the result of the sequences check with value %v would be %t.",
+			test.password_input, test.threshold, test.want_output,
+		)
+	}
+}
+
 // Tests the password validation process
 func TestValidPassword(t *testing.T) {
 	type caseTestValidPassword struct {
 		password          string
-		rules             []utils.Rule
+		rules             []RuleConfig
 		expectedVerify    bool
 		expectedNoMatched []string
 	}
 	tests := []caseTestValidPassword{
 		{
 			password: "aa16a6aAAaaBviniD",
-			rules: []utils.Rule{
+			rules: []RuleConfig{
 				{Rule: "minDigit", Value: 14},
 				{Rule: "noRepeted", Value: 0},
 			},
@@ -241,14 +392,14 @@ func TestValidPassword(t *testing.T) {
 			expectedNoMatched: []string{"minDigit", "noRepeted"},
 		}, {
 			password: "reeepetindocarActEres",
-			rules: []utils.Rule{
+			rules: []RuleConfig{
 				{Rule: "minDigit", Value: 14},
 			},
 			expectedVerify:    false,
 			expectedNoMatched: []string{"minDigit"},
 		}, {
 			password: "bCD3!",
-			rules: []utils.Rule{
+			rules: []RuleConfig{
 				{Rule: "minSize", Value: 8},
 				{Rule: "minLowercase", Value: 1},
 				{Rule: "minUppercase", Value: 1},
@@ -260,7 +411,7 @@ func TestValidPassword(t *testing.T) {
 		},
 		{
 			password: "abcdefgh",
-			rules: []utils.Rule{
+			rules: []RuleConfig{
 				{Rule: "minSize", Value: 8},
 				{Rule: "minLowercase", Value: 0},
 				{Rule: "minUppercase", Value: 0},
@@ -272,7 +423,7 @@ func TestValidPassword(t *testing.T) {
 		},
 		{
 			password: "aAcd$fg1-h",
-			rules: []utils.Rule{
+			rules: []RuleConfig{
 				{Rule: "minSize", Value: 8},
 				{Rule: "minLowercase", Value: 3},
 				{Rule: "minUppercase", Value: 1},
@@ -293,3 +444,112 @@ func TestValidPassword(t *testing.T) {
 			"Test of verification of password %s failed: it was expected that 'matched' would be %v, but it is %v", test.password, test.expectedNoMatched, noMatched)
 	}
 }
+
+// Tests the per-rule results of the password validation process
+func TestCheckPassword(t *testing.T) {
+	rules := []RuleConfig{
+		{Rule: "minSize", Value: 8},
+		{Rule: "minDigit", Value: 4},
+		{Rule: "noRepeted", Value: 0},
+	}
+	expectedResults := []Result{
+		{Rule: "minSize", Required: 8, Actual: 10, Passed: true, Message: "the password has 10 characters, at least 8 required"},
+		{Rule: "minDigit", Required: 4, Actual: 1, Passed: false, Message: "the password has 1 digits, at least 4 required"},
+		{Rule: "noRepeted", Required: 0, Actual: 2, Passed: false, Message: "the longest run of repeated characters in the password has 2 characters, at most 1 allowed"},
+	}
+
+	results := CheckPassword("bookA1!xyz", rules, Options{})
+
+	assert.Equal(t, expectedResults, results)
+}
+
+// Tests the per-rule results in ASCII and Unicode modes
+func TestCheckPasswordUnicode(t *testing.T) {
+	rules := []RuleConfig{
+		{Rule: "minSize", Value: 5},
+		{Rule: "minUppercase", Value: 1},
+		{Rule: "minLowercase", Value: 3},
+		{Rule: "noRepeted", Value: 0},
+	}
+
+	// 4 runes, but 12 bytes
+	verify, noMatched := Summarize(CheckPassword("パスワド", rules[:1], Options{}))
+	assert.True(t, verify)
+	verify, noMatched = Summarize(CheckPassword("パスワド", rules[:1], Options{Unicode: true}))
+	assert.False(t, verify)
+	assert.Equal(t, []string{"minSize"}, noMatched)
+
+	verify, noMatched = Summarize(CheckPassword("Çãozinho", rules, Options{}))
+	assert.False(t, verify)
+	assert.Equal(t, []string{"minUppercase"}, noMatched)
+	verify, _ = Summarize(CheckPassword("Çãozinho", rules, Options{Unicode: true}))
+	assert.True(t, verify)
+}
+
+// Tests the rules that set a maximum amount of something in the password
+func TestMaxRules(t *testing.T) {
+	type caseTestMaxRules struct {
+		password_input string
+		rule           string
+		threshold      int
+		unicode        bool
+		want_output    bool
+	}
+	tests := []caseTestMaxRules{
+		{password_input: "senha1234", rule: "maxSize", threshold: 8, want_output: false},
+		{password_input: "senha123", rule: "maxSize", threshold: 8, want_output: true},
+		{password_input: "パスワード", rule: "maxSize", threshold: 8, want_output: false},
+		{password_input: "パスワード", rule: "maxSize", threshold: 8, unicode: true, want_output: true},
+		{password_input: "SENHa", rule: "maxUppercase", threshold: 3, want_output: false},
+		{password_input: "senHA", rule: "maxLowercase", threshold: 3, want_output: true},
+		{password_input: "s3nh4", rule: "maxDigit", threshold: 0, want_output: false},
+		{password_input: "s3nh4!", rule: "maxSpecialChars", threshold: 1, want_output: true},
+		{password_input: "ÇÃO", rule: "maxUppercase", threshold: 2, want_output: true},
+		{password_input: "ÇÃO", rule: "maxUppercase", threshold: 2, unicode: true, want_output: false},
+	}
+
+	for _, test := range tests {
+		rules := []RuleConfig{{Rule: test.rule, Value: test.threshold}}
+		verify, _ := Summarize(CheckPassword(test.password_input, rules, Options{Unicode: test.unicode}))
+		assert.Equal(t, test.want_output, verify,
+			"Test of verification of password '%s' failed: it was expected that "+
+				"the rule %s with value %v would be %t.",
+			test.password_input, test.rule, test.threshold, test.want_output,
+		)
+	}
+
+	results := CheckPassword("s3nh4", []RuleConfig{{Rule: "maxDigit", Value: 1}}, Options{})
+	assert.Equal(t, "the password has 2 digits, at most 1 allowed", results[0].Message)
+}
+
+// Tests the rule that sets a minimum amount of distinct characters
+func TestMinUniqueChars(t *testing.T) {
+	rules := []RuleConfig{{Rule: "minSize", Value: 10}, {Rule: "minUniqueChars", Value: 6}}
+
+	results := CheckPassword("aAaAaA1!1!", rules, Options{})
+	assert.Equal(t, Result{
+		Rule:     "minUniqueChars",
+		Required: 6,
+		Actual:   4,
+		Passed:   false,
+		Message:  "the password has 4 distinct characters, at least 6 required",
+	}, results[1])
+
+	verify, _ := Summarize(CheckPassword("aAbBcC1!2@", rules, Options{}))
+	assert.True(t, verify)
+}
+
+// Tests the rule that sets a minimum amount of classes of characters
+func TestMinCharClasses(t *testing.T) {
+	rules := []RuleConfig{{Rule: "minCharClasses", Value: 3}}
+
+	verify, _ := Summarize(CheckPassword("senha1234", rules, Options{}))
+	assert.False(t, verify)
+	verify, _ = Summarize(CheckPassword("Senha1234", rules, Options{}))
+	assert.True(t, verify)
+	verify, _ = Summarize(CheckPassword("senha@1234", rules, Options{}))
+	assert.True(t, verify, "any 3 of the 4 classes of characters should be enough")
+
+	assert.Nil(t, CheckConfig(RuleConfig{Rule: "minCharClasses", Value: 4}))
+	assert.NotNil(t, CheckConfig(RuleConfig{Rule: "minCharClasses", Value: 5}))
+}
@@ -0,0 +1,119 @@
+package password
+
+import (
+	"fmt"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// returns the predicate that tells if a character belongs to the class of characters with the given name,
+// accepted as the parameter of the position rules, or nil if there is no such class. In ASCII mode, only
+// ASCII letters and digits are recognized and the special characters are those of the default set.
+func charClassPredicate(name string, opts Options) func(rune) bool {
+	if opts.Unicode {
+		switch name {
+		case "upper":
+			return unicode.IsUpper
+		case "lower":
+			return unicode.IsLower
+		case "digit":
+			return unicode.IsDigit
+		case "letter":
+			return unicode.IsLetter
+		}
+	} else {
+		switch name {
+		case "upper":
+			return func(char rune) bool { return char >= 'A' && char <= 'Z' }
+		case "lower":
+			return func(char rune) bool { return char >= 'a' && char <= 'z' }
+		case "digit":
+			return func(char rune) bool { return char >= '0' && char <= '9' }
+		case "letter":
+			return func(char rune) bool { return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') }
+		}
+	}
+	if name == "special" {
+		return specialCharsPredicate("", opts)
+	}
+	return nil
+}
+
+// CharClass returns the predicate that tells if a character belongs to the class of characters with the given
+// name, one of the classes accepted by the position rules, or nil if there is no such class. The special
+// characters are those of the set chosen by the parameter, as in the minSpecialChars rule.
+func CharClass(name string, param string, opts Options) func(rune) bool {
+	if name == "special" {
+		return specialCharsPredicate(param, opts)
+	}
+	return charClassPredicate(name, opts)
+}
+
+// names of the classes of characters accepted by the position rules, with their description in the messages
+var charClassNames = map[string]string{
+	"upper":   "an uppercase letter",
+	"lower":   "a lowercase letter",
+	"digit":   "a digit",
+	"special": "a special character",
+	"letter":  "a letter",
+}
+
+// positionRule is the Rule implementation of the rules about the class of the first or the last character
+// of the password, whose parameter is the name of the class: "upper", "lower", "digit", "special" or "letter".
+// The firstCharClass and lastCharClass rules require the character to belong to the class, and the
+// firstCharNotClass and lastCharNotClass rules require it not to. The value of the rules is ignored.
+type positionRule struct {
+	// tells if the rule is about the last character, instead of the first one
+	last bool
+	// tells if the character must not belong to the class, instead of belonging to it
+	negated bool
+}
+
+// Check classifies the first or the last character of the password. An empty password has no character of
+// any class.
+func (r positionRule) Check(password string, config RuleConfig, opts Options) Result {
+	result := Result{Rule: config.Rule, Required: config.Value}
+	inClass := charClassPredicate(config.Param, opts)
+	if inClass == nil {
+		result.Message = fmt.Sprintf("the password could not be checked: '%s' is not a class of characters", config.Param)
+		return result
+	}
+
+	position, decode := "first", utf8.DecodeRuneInString
+	if r.last {
+		position, decode = "last", utf8.DecodeLastRuneInString
+	}
+	char, size := decode(password)
+	if size > 0 && inClass(char) {
+		result.Actual = 1
+	}
+	result.Passed = (result.Actual == 1) != r.negated
+
+	switch {
+	case size == 0:
+		result.Message = fmt.Sprintf("the password has no %s character", position)
+	case result.Actual == 1:
+		result.Message = fmt.Sprintf("the %s character of the password is %s", position, charClassNames[config.Param])
+	default:
+		result.Message = fmt.Sprintf("the %s character of the password is not %s", position, charClassNames[config.Param])
+	}
+	if !result.Passed && size > 0 {
+		result.Detail = string(char)
+	}
+	return result
+}
+
+// CheckConfig verifies that the parameter of the rule is the name of a class of characters.
+func (r positionRule) CheckConfig(config RuleConfig) error {
+	if _, ok := charClassNames[config.Param]; !ok {
+		names := make([]string, 0, len(charClassNames))
+		for name := range charClassNames {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("the parameter '%s' of the rule '%s' is invalid. List of accepted classes of characters: %v",
+			config.Param, config.Rule, names)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// unit tests to the rules about the first and the last character of the password
+
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests the classes of characters accepted by the position rules
+func TestCharClassPredicate(t *testing.T) {
+	tests := []struct {
+		class       string
+		char        rune
+		unicode     bool
+		want_output bool
+	}{
+		{class: "upper", char: 'A', want_output: true},
+		{class: "upper", char: 'a', want_output: false},
+		{class: "lower", char: 'a', want_output: true},
+		{class: "digit", char: '7', want_output: true},
+		{class: "letter", char: 'z', want_output: true},
+		{class: "letter", char: '7', want_output: false},
+		{class: "special", char: '!', want_output: true},
+		{class: "special", char: '_', want_output: false},
+		{class: "upper", char: 'Ç', want_output: false},
+		{class: "upper", char: 'Ç', unicode: true, want_output: true},
+		{class: "special", char: '_', unicode: true, want_output: true},
+	}
+
+	for _, test := range tests {
+		result := charClassPredicate(test.class, Options{Unicode: test.unicode})(test.char)
+		assert.Equal(t, test.want_output, result,
+			"it was expected that the membership of '%c' in the class '%s' would be %t", test.char, test.class, test.want_output)
+	}
+	assert.Nil(t, charClassPredicate("symbol", Options{}))
+}
+
+// Tests the rules about the first and the last character of the password
+func TestPositionRules(t *testing.T) {
+	tests := []struct {
+		password_input string
+		rule           string
+		class          string
+		want_output    bool
+	}{
+		{password_input: "Senha123!", rule: "firstCharClass", class: "letter", want_output: true},
+		{password_input: "1Senha23!", rule: "firstCharClass", class: "letter", want_output: false},
+		{password_input: "Senha123!", rule: "lastCharClass", class: "special", want_output: true},
+		{password_input: "1Senha23!", rule: "firstCharNotClass", class: "digit", want_output: false},
+		{password_input: "Senha123!", rule: "firstCharNotClass", class: "digit", want_output: true},
+		{password_input: "Senha123!", rule: "lastCharNotClass", class: "special", want_output: false},
+		{password_input: "Senha!123", rule: "lastCharNotClass", class: "special", want_output: true},
+		{password_input: "", rule: "firstCharClass", class: "letter", want_output: false},
+		{password_input: "", rule: "lastCharNotClass", class: "special", want_output: true},
+	}
+
+	for _, test := range tests {
+		rules := []RuleConfig{{Rule: test.rule, Param: test.class}}
+		verify, _ := Summarize(CheckPassword(test.password_input, rules, Options{}))
+		assert.Equal(t, test.want_output, verify,
+			"Test of verification of password '%s' failed: it was expected that "+
+				"the rule %s with the class %s would be %t.",
+			test.password_input, test.rule, test.class, test.want_output,
+		)
+	}
+
+	assert.Equal(t, Result{
+		Rule:    "firstCharNotClass",
+		Actual:  1,
+		Passed:  false,
+		Message: "the first character of the password is a digit",
+		Detail:  "1",
+	}, positionRule{negated: true}.Check("1Senha23!", RuleConfig{Rule: "firstCharNotClass", Param: "digit"}, Options{}))
+
+	assert.Nil(t, CheckConfig(RuleConfig{Rule: "lastCharClass", Param: "upper"}))
+	assert.NotNil(t, CheckConfig(RuleConfig{Rule: "lastCharClass"}), "the class of characters should be required")
+	assert.NotNil(t, CheckConfig(RuleConfig{Rule: "lastCharClass", Param: "symbol"}))
+}
@@ -0,0 +1,139 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxPreviousPasswords is the largest number of previous passwords the password can be compared with, so that
+// the cost of the minEditDistance rule is bounded.
+const MaxPreviousPasswords = 20
+
+// highest value of the minEditDistance rule. The edit distance is only computed up to the value of the rule,
+// so the value bounds the cost of the comparison of long passwords.
+const maxEditDistance = 64
+
+// returns the edit distance between two passwords, compared case-insensitively, if it is below the limit, or
+// the limit otherwise. The edit distance is the number of characters that have to be inserted, removed or
+// replaced to turn one password into the other (e.g. 1 between "Summer2024!" and "Summer2025!"); the case is
+// ignored, since changing the case of a letter barely changes a password.
+func boundedEditDistance(a string, b string, limit int) int {
+	distance, _ := editDistanceBand(a, b, limit)
+	return distance
+}
+
+// computes the bounded edit distance, returning also the number of cells of the distance matrix computed.
+// Only the cells within the limit of its diagonal are computed, and the computation stops as soon as every
+// cell of a row reaches the limit, so the cost is proportional to the length of the passwords times the
+// limit, instead of the product of their lengths.
+func editDistanceBand(a string, b string, limit int) (int, int) {
+	long, short := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+	if len(long) < len(short) {
+		long, short = short, long
+	}
+	if len(long)-len(short) >= limit {
+		return limit, 0
+	}
+
+	min := func(values ...int) int {
+		smallest := values[0]
+		for _, value := range values[1:] {
+			if value < smallest {
+				smallest = value
+			}
+		}
+		return smallest
+	}
+
+	cells := 0
+	previous := make([]int, len(short)+1)
+	current := make([]int, len(short)+1)
+	for j := range previous {
+		previous[j] = min(j, limit)
+	}
+	for i := 1; i <= len(long); i++ {
+		low, high := i-limit, min(len(short), i+limit)
+		if low < 1 {
+			low = 1
+		}
+		// the cells outside of the band are at least the limit away
+		current[low-1] = limit
+		if low == 1 {
+			current[0] = min(i, limit)
+		}
+		smallest := current[low-1]
+		for j := low; j <= high; j++ {
+			cost := 1
+			if long[i-1] == short[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j-1]+cost, previous[j]+1, current[j-1]+1, limit)
+			smallest = min(smallest, current[j])
+			cells++
+		}
+		if high < len(short) {
+			current[high+1] = limit
+		}
+		if smallest >= limit {
+			return limit, cells
+		}
+		previous, current = current, previous
+	}
+	return previous[len(short)], cells
+}
+
+// returns the smallest edit distance between the password and the previous passwords, up to the limit, and
+// whether there was any previous password to compare with
+func closestPrevious(password string, previous []string, limit int) (int, bool) {
+	closest, found := limit, false
+	for _, previous_password := range previous {
+		found = true
+		if distance := boundedEditDistance(password, previous_password, closest); distance < closest {
+			closest = distance
+		}
+	}
+	return closest, found
+}
+
+// editDistanceRule is the Rule implementation of the minEditDistance rule, which rejects the passwords too
+// similar to one of the previous passwords of the user given in the options. The value of the rule is the
+// minimum edit distance required between the password and each previous password.
+type editDistanceRule struct{}
+
+// Check compares the password with each previous password. The distance is only computed up to the value of
+// the rule, so a valid password is reported as at least that many edits away.
+func (editDistanceRule) Check(password string, config RuleConfig, opts Options) Result {
+	closest, found := closestPrevious(password, opts.PreviousPasswords, config.Value)
+	if !found {
+		return Result{
+			Rule:     config.Rule,
+			Required: config.Value,
+			Passed:   true,
+			Message:  "the password was not compared with previous passwords, since none was given",
+		}
+	}
+
+	result := Result{
+		Rule:     config.Rule,
+		Required: config.Value,
+		Actual:   closest,
+		Passed:   closest >= config.Value,
+		Message:  fmt.Sprintf("the password is at least %d edits away from every previous password", closest),
+	}
+	if !result.Passed {
+		result.Message = fmt.Sprintf("the password is %d edits away from the closest previous password, at least %d required",
+			closest, config.Value)
+	}
+	return result
+}
+
+// CheckConfig verifies that the value of the rule is not above maxEditDistance and that no parameter is given.
+func (editDistanceRule) CheckConfig(config RuleConfig) error {
+	if config.Value > maxEditDistance {
+		return fmt.Errorf("the value %d of the rule '%s' is invalid. The maximum is %d", config.Value, config.Rule, maxEditDistance)
+	}
+	if config.Param != "" {
+		return fmt.Errorf("the rule '%s' does not accept a parameter", config.Rule)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// unit tests to the comparison of the password with the previous passwords
+
+package password
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// returns the edit distance between two passwords, compared case-insensitively, computing the whole distance
+// matrix, as a reference for the bounded edit distance
+func editDistance(a string, b string) int {
+	x, y := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+	previous := make([]int, len(y)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		current := make([]int, len(y)+1)
+		current[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous = current
+	}
+	return previous[len(y)]
+}
+
+// Tests the edit distance between two passwords
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b        string
+		want_output int
+	}{
+		{a: "Summer2024!", b: "Summer2025!", want_output: 1},
+		{a: "Summer2024!", b: "SUMMER2024!", want_output: 0},
+		{a: "Summer2024!", b: "Summer2024", want_output: 1},
+		{a: "Summer2024!", b: "Winter2024!", want_output: 4},
+		{a: "", b: "abc", want_output: 3},
+	}
+
+	for _, test := range tests {
+		result := editDistance(test.a, test.b)
+		assert.Equal(t, test.want_output, boundedEditDistance(test.a, test.b, maxEditDistance))
+		assert.Equal(t, test.want_output, result,
+			"it was expected that the edit distance between '%s' and '%s' would be %d", test.a, test.b, test.want_output)
+	}
+}
+
+// Tests that the bounded edit distance is the edit distance, up to the limit
+func TestBoundedEditDistance(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomPassword := func() string {
+		chars := make([]byte, random.Intn(12))
+		for i := range chars {
+			chars[i] = "abcAB1!"[random.Intn(7)]
+		}
+		return string(chars)
+	}
+
+	for i := 0; i < 2000; i++ {
+		a, b, limit := randomPassword(), randomPassword(), 1+random.Intn(8)
+		want_output := editDistance(a, b)
+		if want_output > limit {
+			want_output = limit
+		}
+		assert.Equal(t, want_output, boundedEditDistance(a, b, limit),
+			"it was expected that the edit distance between '%s' and '%s' up to %d would be %d", a, b, limit, want_output)
+	}
+}
+
+// Tests the minEditDistance rule
+func TestMinEditDistance(t *testing.T) {
+	opts := Options{PreviousPasswords: []string{"Winter2023!", "Summer2024!"}}
+	config := RuleConfig{Rule: "minEditDistance", Value: 3}
+
+	assert.Equal(t, Result{
+		Rule:     "minEditDistance",
+		Required: 3,
+		Actual:   1,
+		Passed:   false,
+		Message:  "the password is 1 edits away from the closest previous password, at least 3 required",
+	}, editDistanceRule{}.Check("Summer2025!", config, opts))
+	assert.True(t, editDistanceRule{}.Check("x7#Qm!2vLp9@", config, opts).Passed)
+	assert.Equal(t, "the password is at least 3 edits away from every previous password",
+		editDistanceRule{}.Check("x7#Qm!2vLp9@", config, opts).Message)
+	assert.True(t, editDistanceRule{}.Check("Summer2025!", config, Options{}).Passed,
+		"without previous passwords, every password should pass")
+
+	assert.Nil(t, CheckConfig(RuleConfig{Rule: "minEditDistance", Value: maxEditDistance}))
+	assert.NotNil(t, CheckConfig(RuleConfig{Rule: "minEditDistance", Value: maxEditDistance + 1}))
+	assert.NotNil(t, CheckConfig(RuleConfig{Rule: "minEditDistance", Value: 3, Param: "x"}))
+}
+
+// Tests that the comparison of long passwords is bounded by the value of the rule
+func TestMinEditDistanceWithLongPasswords(t *testing.T) {
+	previous := make([]string, MaxPreviousPasswords)
+	for i := range previous {
+		previous[i] = strings.Repeat("ab", 2500) + string(rune('a'+i))
+	}
+	config := RuleConfig{Rule: "minEditDistance", Value: maxEditDistance}
+
+	result := editDistanceRule{}.Check(strings.Repeat("xy", 2500), config, Options{PreviousPasswords: previous})
+	assert.True(t, result.Passed)
+
+	// only the band of 2*limit+1 cells around the diagonal of each row is computed
+	_, cells := editDistanceBand(strings.Repeat("xy", 2500), previous[0], maxEditDistance)
+	assert.LessOrEqual(t, cells, 5001*(2*maxEditDistance+1))
+	_, cells = editDistanceBand(strings.Repeat("x", 5000), strings.Repeat("y", 5000), 3)
+	assert.LessOrEqual(t, cells, 3*(2*3+1), "the computation should stop at the first row that reaches the limit")
+}
@@ -0,0 +1,88 @@
+package password
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"unicode/utf8"
+)
+
+// limits of the patterns accepted by the matchesRegex and notMatchesRegex rules. RE2 patterns run in linear
+// time, but the size of the compiled program grows with the pattern (e.g. with "(a{1,100}){1,100}"), so both
+// the length of the pattern and the number of instructions of its program are limited.
+const (
+	maxRegexLength       = 256
+	maxRegexInstructions = 2000
+)
+
+// compiles the pattern given as the parameter of the matchesRegex and notMatchesRegex rules, checking that it
+// is a valid RE2 pattern within the limits of length and complexity
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if utf8.RuneCountInString(pattern) > maxRegexLength {
+		return nil, fmt.Errorf("the pattern is longer than %d characters", maxRegexLength)
+	}
+
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	program, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return nil, err
+	}
+	if len(program.Inst) > maxRegexInstructions {
+		return nil, fmt.Errorf("the pattern is too complex")
+	}
+	return regexp.Compile(pattern)
+}
+
+// regexRule is the Rule implementation of the matchesRegex and notMatchesRegex rules, whose parameter is an
+// RE2 pattern (e.g. "^[A-Za-z]" for passwords that start with a letter). The matchesRegex rule rejects the
+// passwords that do not match the pattern, and the notMatchesRegex rule rejects the passwords that match it.
+// The value of the rules is ignored.
+type regexRule struct {
+	// tells if the password must not match the pattern, instead of matching it
+	negated bool
+}
+
+// Check matches the password against the pattern. The part of the password matched by a pattern it must not
+// match is given in the detail of the result.
+func (r regexRule) Check(password string, config RuleConfig, opts Options) Result {
+	result := Result{Rule: config.Rule, Required: config.Value}
+	pattern, err := compileRegex(config.Param)
+	if err != nil {
+		result.Message = fmt.Sprintf("the password could not be checked: %v", err)
+		return result
+	}
+
+	match := pattern.FindStringIndex(password)
+	if match != nil {
+		result.Actual = 1
+	}
+	result.Passed = (match != nil) != r.negated
+
+	switch {
+	case match != nil && r.negated:
+		result.Message = fmt.Sprintf("the password matches the pattern '%s', which is not allowed", config.Param)
+		result.Detail = password[match[0]:match[1]]
+	case match != nil:
+		result.Message = fmt.Sprintf("the password matches the pattern '%s'", config.Param)
+	case r.negated:
+		result.Message = fmt.Sprintf("the password does not match the pattern '%s'", config.Param)
+	default:
+		result.Message = fmt.Sprintf("the password does not match the pattern '%s', which is required", config.Param)
+	}
+	return result
+}
+
+// CheckConfig verifies that the parameter of the rule is a valid RE2 pattern, within the limits of length
+// and complexity.
+func (r regexRule) CheckConfig(config RuleConfig) error {
+	if config.Param == "" {
+		return fmt.Errorf("the rule '%s' requires a regular expression as its parameter", config.Rule)
+	}
+	if _, err := compileRegex(config.Param); err != nil {
+		return fmt.Errorf("the parameter '%s' of the rule '%s' is invalid: %w", config.Param, config.Rule, err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// unit tests to the rules based on regular expressions
+
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests the limits of the patterns
+func TestCompileRegex(t *testing.T) {
+	tests := []struct {
+		pattern     string
+		want_output bool
+	}{
+		{pattern: "^[A-Za-z]", want_output: true},
+		{pattern: `\d{2,}$`, want_output: true},
+		{pattern: "(?i)password", want_output: true},
+		{pattern: "[a-z", want_output: false},
+		{pattern: `(\w)\1`, want_output: false},
+		{pattern: strings.Repeat("a", maxRegexLength+1), want_output: false},
+		{pattern: "((a{1,100}){1,100}){1,100}", want_output: false},
+	}
+
+	for _, test := range tests {
+		_, err := compileRegex(test.pattern)
+		assert.Equal(t, test.want_output, err == nil,
+			"it was expected that the validity of the pattern '%s' would be %t", test.pattern, test.want_output)
+	}
+}
+
+// Tests the matchesRegex and notMatchesRegex rules
+func TestRegexRules(t *testing.T) {
+	startsWithLetter := RuleConfig{Rule: "matchesRegex", Param: "^[A-Za-z]"}
+	assert.True(t, regexRule{}.Check("Senha123", startsWithLetter, Options{}).Passed)
+	assert.Equal(t, Result{
+		Rule:    "matchesRegex",
+		Passed:  false,
+		Message: "the password does not match the pattern '^[A-Za-z]', which is required",
+	}, regexRule{}.Check("1Senha23", startsWithLetter, Options{}))
+
+	noYear := RuleConfig{Rule: "notMatchesRegex", Param: `(19|20)\d\d`}
+	assert.True(t, regexRule{negated: true}.Check("Senha123", noYear, Options{}).Passed)
+	assert.Equal(t, Result{
+		Rule:    "notMatchesRegex",
+		Actual:  1,
+		Passed:  false,
+		Message: `the password matches the pattern '(19|20)\d\d', which is not allowed`,
+		Detail:  "2024",
+	}, regexRule{negated: true}.Check("Summer2024!", noYear, Options{}))
+
+	assert.NotNil(t, CheckConfig(RuleConfig{Rule: "matchesRegex"}), "the pattern should be required")
+	assert.NotNil(t, CheckConfig(RuleConfig{Rule: "notMatchesRegex", Param: "[a-z"}))
+}
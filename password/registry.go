@@ -0,0 +1,125 @@
+package password
+
+import (
+	"fmt"
+	"sync"
+)
+
+// RuleConfig is a rule chosen by the user, identified by its name, together with the value that
+// configures it (e.g. {Rule: "minDigit", Value: 4}) and an optional parameter, whose meaning depends
+// on the rule (e.g. the set of special characters of minSpecialChars).
+type RuleConfig struct {
+	Rule  string `json:"rule"`
+	Value int    `json:"value"`
+	Param string `json:"param,omitempty"`
+}
+
+// Options holds the settings of a validation request, which apply to every rule of the request.
+type Options struct {
+	// Unicode selects the Unicode mode, in which the characters are classified according to the unicode
+	// package (e.g. "Ç" is an uppercase letter) and the length of the password is counted in runes
+	// instead of bytes. Otherwise only ASCII letters and digits are recognized.
+	Unicode bool
+	// User is the information about the user the password is for, checked by the noUserInfo rule.
+	User UserInfo
+	// PreviousPasswords are the passwords the user had before, checked by the minEditDistance rule.
+	PreviousPasswords []string
+}
+
+// Rule is implemented by every password validation rule known to the validator. Check applies the
+// rule, configured as chosen by the user, to the password and returns the outcome.
+type Rule interface {
+	Check(password string, config RuleConfig, opts Options) Result
+}
+
+// The RuleFunc type is an adapter to allow the use of ordinary functions as rules.
+type RuleFunc func(password string, config RuleConfig, opts Options) Result
+
+// Check calls f(password, config, opts).
+func (f RuleFunc) Check(password string, config RuleConfig, opts Options) Result {
+	return f(password, config, opts)
+}
+
+// ConfigChecker is implemented by the rules that restrict their configuration beyond the non-negative
+// value required of every rule, e.g. the rules that accept a parameter. CheckConfig returns an error
+// describing why the configuration chosen by the user is invalid.
+type ConfigChecker interface {
+	CheckConfig(config RuleConfig) error
+}
+
+// The registry holds every rule that can be chosen by the user, indexed by name. The names are also kept
+// in registration order, so that the list of accepted rules is always presented in the same order.
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Rule{}
+	ruleNames  []string
+)
+
+// Register makes a rule available to the validator and to the input checking under the given name.
+// It is meant to be called at startup (e.g. in an init function), so that packages outside of this
+// one can add their own rules. Registering a rule under the name of an existing rule replaces it.
+// Note that, to be accepted by the API, either in a request or in a policy, the name must also be a value
+// of the RuleName enum of the GraphQL schema.
+func Register(name string, rule Rule) {
+	if name == "" {
+		panic("password: Register rule with empty name")
+	}
+	if rule == nil {
+		panic(fmt.Sprintf("password: Register rule '%s' is nil", name))
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[name]; !exists {
+		ruleNames = append(ruleNames, name)
+	}
+	registry[name] = rule
+}
+
+// Unregister removes the rule registered under the given name, if any. It is meant for tests that
+// register a rule of their own and must leave the registry as they found it.
+func Unregister(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[name]; !exists {
+		return
+	}
+	delete(registry, name)
+	for i, registered := range ruleNames {
+		if registered == name {
+			ruleNames = append(ruleNames[:i:i], ruleNames[i+1:]...)
+			break
+		}
+	}
+}
+
+// Lookup returns the rule registered under the given name, and whether such a rule exists.
+func Lookup(name string) (Rule, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	rule, ok := registry[name]
+	return rule, ok
+}
+
+// RuleNames returns the names of all registered rules, in registration order.
+func RuleNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]string(nil), ruleNames...)
+}
+
+// CheckConfig verifies the configuration of a registered rule, through the ConfigChecker interface when
+// the rule implements it. Rules that do not implement it accept no parameter.
+func CheckConfig(config RuleConfig) error {
+	rule, ok := Lookup(config.Rule)
+	if !ok {
+		return fmt.Errorf("the rule '%s' is not registered", config.Rule)
+	}
+	if checker, ok := rule.(ConfigChecker); ok {
+		return checker.CheckConfig(config)
+	}
+	if config.Param != "" {
+		return fmt.Errorf("the rule '%s' does not accept a parameter", config.Rule)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// unit tests to the rule registry
+
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests that the built-in rules are registered in order
+func TestBuiltinRulesRegistered(t *testing.T) {
+	names := RuleNames()
+	assert.Equal(t, []string{
+		"minSize", "minUppercase", "minLowercase", "minDigit", "minSpecialChars", "noRepeted",
+	}, names[:6])
+
+	for _, name := range names[:6] {
+		_, ok := Lookup(name)
+		assert.True(t, ok, "the built-in rule '%s' is not registered", name)
+	}
+}
+
+// Tests that a rule registered from outside the built-in set is used by the validator
+func TestRegisterCustomRule(t *testing.T) {
+	t.Cleanup(func() { Unregister("noCompanyName") })
+	Register("noCompanyName", RuleFunc(func(password string, config RuleConfig, opts Options) Result {
+		passed := !strings.Contains(strings.ToLower(password), "acme")
+		return Result{Rule: config.Rule, Required: config.Value, Passed: passed}
+	}))
+
+	_, ok := Lookup("noCompanyName")
+	assert.True(t, ok)
+	assert.Contains(t, RuleNames(), "noCompanyName")
+
+	verify, noMatched := ValidPassword("Acme2023!", []RuleConfig{
+		{Rule: "minSize", Value: 8},
+		{Rule: "noCompanyName", Value: 0},
+	})
+	assert.False(t, verify)
+	assert.Equal(t, []string{"noCompanyName"}, noMatched)
+}
+
+// Tests that registering a rule under an existing name replaces it, keeping its position
+func TestRegisterReplacesRule(t *testing.T) {
+	t.Cleanup(func() { Unregister("alwaysFails") })
+	Register("alwaysFails", RuleFunc(func(password string, config RuleConfig, opts Options) Result {
+		return Result{Rule: config.Rule}
+	}))
+	before := RuleNames()
+
+	Register("alwaysFails", RuleFunc(func(password string, config RuleConfig, opts Options) Result {
+		return Result{Rule: config.Rule, Passed: true}
+	}))
+
+	assert.Equal(t, before, RuleNames())
+	verify, _ := ValidPassword("senha", []RuleConfig{{Rule: "alwaysFails", Value: 0}})
+	assert.True(t, verify)
+}
+
+// Tests that an unregistered rule is removed from the registry and from the list of names
+func TestUnregisterRule(t *testing.T) {
+	before := RuleNames()
+	Register("alwaysPasses", RuleFunc(func(password string, config RuleConfig, opts Options) Result {
+		return Result{Rule: config.Rule, Passed: true}
+	}))
+
+	Unregister("alwaysPasses")
+
+	_, ok := Lookup("alwaysPasses")
+	assert.False(t, ok)
+	assert.Equal(t, before, RuleNames())
+}
+
+// Tests that an unregistered rule never passes
+func TestCheckPasswordUnregisteredRule(t *testing.T) {
+	results := CheckPassword("senha", []RuleConfig{{Rule: "unknownRule", Value: 1}}, Options{})
+
+	assert.Equal(t, []Result{{
+		Rule:     "unknownRule",
+		Required: 1,
+		Message:  "the rule 'unknownRule' is not registered",
+	}}, results)
+}
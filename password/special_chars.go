@@ -0,0 +1,104 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// default set of special characters of the minSpecialChars rule, used when no set is chosen by the user
+const defaultSpecialChars = "!@#$%^&*()-+/{}[]"
+
+// special characters recommended by OWASP: the space and every ASCII punctuation character
+const owaspSpecialChars = " !\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+// Names of the predefined sets of special characters that can be chosen as the parameter of the
+// minSpecialChars rule. Any other parameter is taken as the set of special characters itself.
+const (
+	SpecialCharsOWASP           = "owasp"
+	SpecialCharsNonAlphanumeric = "nonAlphanumeric"
+)
+
+// builds a predicate that tells if a character belongs to the given set of characters
+func inSet(chars string) func(rune) bool {
+	return func(char rune) bool {
+		return strings.ContainsRune(chars, char)
+	}
+}
+
+// checks if a character is neither a letter nor a digit. In ASCII mode, every character outside
+// of A-Z, a-z and 0-9 (including non-ASCII letters) is considered non-alphanumeric.
+func isNonAlphanumeric(unicodeMode bool) func(rune) bool {
+	return func(char rune) bool {
+		if unicodeMode {
+			return !unicode.IsLetter(char) && !unicode.IsDigit(char)
+		}
+		return !isASCIIAlphanumeric(char)
+	}
+}
+
+// returns the predicate that tells which characters are special, according to the parameter of the
+// rule: one of the predefined sets or the set of special characters itself. An empty parameter selects
+// the default set, or any punctuation and symbol character in Unicode mode.
+func specialCharsPredicate(param string, opts Options) func(rune) bool {
+	switch param {
+	case "":
+		if opts.Unicode {
+			return func(char rune) bool { return unicode.IsPunct(char) || unicode.IsSymbol(char) }
+		}
+		return inSet(defaultSpecialChars)
+	case SpecialCharsOWASP:
+		return inSet(owaspSpecialChars)
+	case SpecialCharsNonAlphanumeric:
+		return isNonAlphanumeric(opts.Unicode)
+	default:
+		return inSet(param)
+	}
+}
+
+// specialCharsRule is the Rule implementation of the rules about special characters, which can be
+// configured with the set of characters considered special through the parameter of the rule.
+type specialCharsRule struct {
+	// builds the rule that applies to the special characters identified by the predicate
+	build func(isSpecial func(rune) bool) checkedRule
+}
+
+// Check applies the rule with the set of special characters chosen by the user.
+func (r specialCharsRule) Check(password string, config RuleConfig, opts Options) Result {
+	isSpecial := specialCharsPredicate(config.Param, opts)
+	return r.build(isSpecial).Check(password, config, Options{})
+}
+
+// CheckConfig verifies that the parameter of the rule is a predefined set or a set of special characters.
+// Letters and digits are not accepted in a custom set, as they are most likely a misspelled predefined set.
+func (r specialCharsRule) CheckConfig(config RuleConfig) error {
+	switch config.Param {
+	case "", SpecialCharsOWASP, SpecialCharsNonAlphanumeric:
+		return nil
+	}
+	if strings.IndexFunc(config.Param, func(char rune) bool { return unicode.IsLetter(char) || unicode.IsDigit(char) }) >= 0 {
+		return fmt.Errorf("the parameter '%s' of the rule '%s' is invalid. It must be '%s', '%s' or a set of special characters without letters and digits",
+			config.Param, config.Rule, SpecialCharsOWASP, SpecialCharsNonAlphanumeric)
+	}
+	return nil
+}
+
+// builds the minSpecialChars rule for the special characters identified by the predicate
+func minSpecialCharsRule(isSpecial func(rune) bool) checkedRule {
+	measure := func(password string) int { return countRunes(password, isSpecial) }
+	return checkedRule{
+		check:   atLeast(measure),
+		measure: measure,
+		message: minMessage("special characters"),
+	}
+}
+
+// builds the maxSpecialChars rule for the special characters identified by the predicate
+func maxSpecialCharsRule(isSpecial func(rune) bool) checkedRule {
+	measure := func(password string) int { return countRunes(password, isSpecial) }
+	return checkedRule{
+		check:   atMost(measure),
+		measure: measure,
+		message: maxMessage("special characters"),
+	}
+}
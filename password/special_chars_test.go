@@ -0,0 +1,66 @@
+// unit tests to the configurable set of special characters
+
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests the count of special characters with each set that can be chosen by the user
+func TestSpecialCharsPredicate(t *testing.T) {
+	tests := []struct {
+		password_input string
+		param          string
+		unicode        bool
+		want_output    int
+	}{
+		{password_input: "a_b.c?d~e=f g-h*i,", param: "", want_output: 2},
+		{password_input: "a_b.c?d~e=f g-h*i,", param: SpecialCharsOWASP, want_output: 9},
+		{password_input: "a_b.c?d~e=f g-h*i,", param: SpecialCharsNonAlphanumeric, want_output: 9},
+		{password_input: "a_b.c?d~e=f g-h*i,", param: "_.", want_output: 2},
+		{password_input: "senhaçã€", param: SpecialCharsNonAlphanumeric, want_output: 3},
+		{password_input: "senhaçã€", param: SpecialCharsNonAlphanumeric, unicode: true, want_output: 1},
+		{password_input: "senhaçã€ !", param: "", unicode: true, want_output: 2},
+	}
+
+	for _, test := range tests {
+		result := countRunes(test.password_input, specialCharsPredicate(test.param, Options{Unicode: test.unicode}))
+		assert.Equal(t, test.want_output, result,
+			"Test of verification of password '%s' failed: it was expected that "+
+				"the number of special characters of the set '%s' would be %v, but it is %v",
+			test.password_input, test.param, test.want_output, result,
+		)
+	}
+}
+
+// Tests the validation of the parameter of the minSpecialChars rule
+func TestSpecialCharsCheckConfig(t *testing.T) {
+	valid := []string{"", SpecialCharsOWASP, SpecialCharsNonAlphanumeric, "!_.? ", "€£"}
+	for _, param := range valid {
+		err := CheckConfig(RuleConfig{Rule: "minSpecialChars", Value: 1, Param: param})
+		assert.Nil(t, err, "the parameter '%s' should be accepted", param)
+	}
+
+	invalid := []string{"owsap", "!1", "abc"}
+	for _, param := range invalid {
+		err := CheckConfig(RuleConfig{Rule: "minSpecialChars", Value: 1, Param: param})
+		assert.NotNil(t, err, "the parameter '%s' should not be accepted", param)
+	}
+}
+
+// Tests the minSpecialChars rule with a set of special characters chosen by the user
+func TestMinSpecialCharsWithParam(t *testing.T) {
+	rules := []RuleConfig{{Rule: "minSpecialChars", Value: 2, Param: "_."}}
+
+	results := CheckPassword("minha_senha.", rules, Options{})
+
+	assert.Equal(t, []Result{{
+		Rule:     "minSpecialChars",
+		Required: 2,
+		Actual:   2,
+		Passed:   true,
+		Message:  "the password has 2 special characters, at least 2 required",
+	}}, results)
+}
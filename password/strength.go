@@ -0,0 +1,104 @@
+package password
+
+import (
+	"math"
+	"unicode/utf8"
+)
+
+// sizes of the character pools used to estimate the entropy of a password
+const (
+	lowercasePool = 26
+	uppercasePool = 26
+	digitPool     = 10
+	symbolPool    = 33  // printable ASCII characters that are neither letters nor digits
+	nonASCIIPool  = 100 // rough estimate, as the actual alphabet of non-ASCII passwords is unknown
+)
+
+// bits counted for a character that repeats the previous one or is next to it in a sequence ("aaa", "abc",
+// "321"), since such characters are easily guessed once the first character of the run is known
+const patternCharBits = 1.0
+
+// entropy thresholds (in bits) of the strength scores 1, 2, 3 and 4. Passwords below the first
+// threshold have score 0.
+var scoreThresholds = []float64{28, 36, 60, 128}
+
+// calculates the size of the pool of characters a password was possibly drawn from, based on the
+// classes of characters that appear in it
+func poolSize(password string) int {
+	pool := 0
+	if countLowerCaseChars(password) > 0 {
+		pool += lowercasePool
+	}
+	if countUppercaseChars(password) > 0 {
+		pool += uppercasePool
+	}
+	if countDigits(password) > 0 {
+		pool += digitPool
+	}
+
+	hasSymbol, hasNonASCII := false, false
+	for _, char := range password {
+		switch {
+		case char >= utf8.RuneSelf:
+			hasNonASCII = true
+		case !isASCIIAlphanumeric(char):
+			hasSymbol = true
+		}
+	}
+	if hasSymbol {
+		pool += symbolPool
+	}
+	if hasNonASCII {
+		pool += nonASCIIPool
+	}
+	return pool
+}
+
+// checks if a character is an ASCII letter or digit
+func isASCIIAlphanumeric(char rune) bool {
+	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
+}
+
+// Entropy estimates the entropy of a password in bits. Each character adds log2 of the size of the
+// pool of characters the password uses (e.g. 26 + 10 for a password with lowercase letters and digits),
+// except the characters that repeat the previous character or follow it in an ascending or descending
+// sequence, which add a single bit, because they are part of a predictable pattern.
+func Entropy(password string) float64 {
+	pool := poolSize(password)
+	if pool == 0 {
+		return 0
+	}
+	charBits := math.Log2(float64(pool))
+
+	var entropy float64
+	var prevChar rune
+	for i, char := range []rune(password) {
+		step := char - prevChar
+		if i > 0 && (step == 0 || step == 1 || step == -1) {
+			entropy += patternCharBits
+		} else {
+			entropy += charBits
+		}
+		prevChar = char
+	}
+	return math.Round(entropy*100) / 100
+}
+
+// Score converts an entropy estimate into a strength score from 0 (very weak) to 4 (very strong).
+func Score(entropy float64) int {
+	score := 0
+	for _, threshold := range scoreThresholds {
+		if entropy >= threshold {
+			score++
+		}
+	}
+	return score
+}
+
+// Strength returns the strength score (0 to 4) and the estimated entropy in bits of a password.
+// Unlike ValidPassword, it does not depend on any rule, so it can be used to show a strength meter
+// even when the rules chosen by the user are trivially satisfied.
+func Strength(password string) (int, float64) {
+	entropy := Entropy(password)
+	return Score(entropy), entropy
+}
@@ -0,0 +1,91 @@
+// unit tests to the password strength estimation
+
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests the size of the pool of characters used by a string
+func TestPoolSize(t *testing.T) {
+	tests := []struct {
+		password_input string
+		want_output    int
+	}{
+		{password_input: "", want_output: 0},
+		{password_input: "abc", want_output: 26},
+		{password_input: "abcD1", want_output: 62},
+		{password_input: "abcD1_", want_output: 95},
+		{password_input: "senha ção", want_output: 159},
+	}
+
+	for _, test := range tests {
+		result := poolSize(test.password_input)
+		assert.Equal(t, test.want_output, result,
+			"Test of verification of password '%s' failed: it was expected that "+
+				"the pool size would be %v, but it is %v",
+			test.password_input, test.want_output, result,
+		)
+	}
+}
+
+// Tests the entropy estimate of a string
+func TestEntropy(t *testing.T) {
+	tests := []struct {
+		password_input string
+		want_output    float64
+	}{
+		{password_input: "", want_output: 0},
+		{password_input: "a", want_output: 4.7},
+		{password_input: "aaaa", want_output: 7.7},   // 4.7 + 3 repeated characters
+		{password_input: "abcd", want_output: 7.7},   // 4.7 + 3 characters in sequence
+		{password_input: "dcba", want_output: 7.7},   // 4.7 + 3 characters in descending sequence
+		{password_input: "acbd", want_output: 15.1},  // only 'c' -> 'b' is a sequence
+		{password_input: "kq8z", want_output: 20.68}, // 4 * log2(36)
+	}
+
+	for _, test := range tests {
+		result := Entropy(test.password_input)
+		assert.InDelta(t, test.want_output, result, 0.01,
+			"Test of verification of password '%s' failed: it was expected that "+
+				"the entropy would be %v, but it is %v",
+			test.password_input, test.want_output, result,
+		)
+	}
+}
+
+// Tests the conversion of the entropy into a strength score
+func TestScore(t *testing.T) {
+	tests := []struct {
+		entropy_input float64
+		want_output   int
+	}{
+		{entropy_input: 0, want_output: 0},
+		{entropy_input: 27.9, want_output: 0},
+		{entropy_input: 28, want_output: 1},
+		{entropy_input: 40, want_output: 2},
+		{entropy_input: 75, want_output: 3},
+		{entropy_input: 130, want_output: 4},
+	}
+
+	for _, test := range tests {
+		result := Score(test.entropy_input)
+		assert.Equal(t, test.want_output, result,
+			"Test of verification of entropy %v failed: it was expected that "+
+				"the score would be %v, but it is %v",
+			test.entropy_input, test.want_output, result,
+		)
+	}
+}
+
+// Tests the strength of passwords, even when they satisfy the usual rules
+func TestStrength(t *testing.T) {
+	score, entropy := Strength("Abcd1234!")
+	assert.Equal(t, 1, score, "a password made of sequences must be considered weak")
+	assert.InDelta(t, 31.28, entropy, 0.01)
+
+	score, _ = Strength("x7#Qm!2vLp9@")
+	assert.Equal(t, 3, score)
+}
@@ -0,0 +1,110 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// default length of the shortest piece of user information looked up by the noUserInfo rule, used when
+// its value is 0. Shorter pieces (e.g. the initials of a name) would reject too many passwords.
+const defaultUserInfoLength = 3
+
+// UserInfo holds the information about the user the password is for, against which the noUserInfo
+// rule checks the password. Every field is optional.
+type UserInfo struct {
+	Username    string
+	Email       string
+	FirstName   string
+	LastName    string
+	CompanyName string
+}
+
+// a piece of information about the user looked up in the password, together with the description of
+// the attribute it comes from (e.g. "first name")
+type userToken struct {
+	attribute string
+	token     string
+}
+
+// splits a value into its words, separated by anything that is not a letter or a digit
+// (e.g. "john.doe" -> "john", "doe")
+func splitWords(value string) []string {
+	return strings.FieldsFunc(value, func(char rune) bool {
+		return !unicode.IsLetter(char) && !unicode.IsDigit(char)
+	})
+}
+
+// returns the pieces of information about the user looked up in the password, in lowercase: each value,
+// the local part of the email and their words, when they are at least minLength characters long
+func userTokens(user UserInfo, minLength int) []userToken {
+	localPart, _, _ := strings.Cut(user.Email, "@")
+	attributes := []struct {
+		name  string
+		value string
+	}{
+		{name: "username", value: user.Username},
+		{name: "email", value: localPart},
+		{name: "first name", value: user.FirstName},
+		{name: "last name", value: user.LastName},
+		{name: "company name", value: user.CompanyName},
+	}
+
+	var tokens []userToken
+	for _, attribute := range attributes {
+		value := strings.ToLower(strings.TrimSpace(attribute.value))
+		candidates := []string{value}
+		for _, word := range splitWords(value) {
+			if word != value {
+				candidates = append(candidates, word)
+			}
+		}
+		for _, token := range candidates {
+			if utf8.RuneCountInString(token) >= minLength {
+				tokens = append(tokens, userToken{attribute: attribute.name, token: token})
+			}
+		}
+	}
+	return tokens
+}
+
+// returns the length of the shortest piece of user information looked up by the noUserInfo rule
+func userInfoLength(value int) int {
+	if value == 0 {
+		return defaultUserInfoLength
+	}
+	return value
+}
+
+// userInfoRule is the Rule implementation of the noUserInfo rule, which rejects the passwords that contain
+// information about the user given in the options (e.g. "john.doe@acme.com" cannot use "JohnDoe2023").
+// The value of the rule is the length of the shortest piece of information looked up.
+type userInfoRule struct{}
+
+// Check looks for the information about the user in the password, case-insensitively. The longest piece
+// of information found is reported.
+func (userInfoRule) Check(password string, config RuleConfig, opts Options) Result {
+	result := Result{
+		Rule:     config.Rule,
+		Required: config.Value,
+		Passed:   true,
+		Message:  "the password does not contain information about the user",
+	}
+
+	lower := strings.ToLower(password)
+	var found userToken
+	for _, token := range userTokens(opts.User, userInfoLength(config.Value)) {
+		if strings.Contains(lower, token.token) && len(token.token) > len(found.token) {
+			found = token
+		}
+	}
+
+	if found.token != "" {
+		result.Actual = utf8.RuneCountInString(found.token)
+		result.Passed = false
+		result.Message = fmt.Sprintf("the password contains the %s of the user", found.attribute)
+		result.Detail = found.token
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+// unit tests to the check of the password against the information about the user
+
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests the pieces of information about the user looked up in the password
+func TestUserTokens(t *testing.T) {
+	user := UserInfo{Username: "jdoe", Email: "John.Doe@acme.com", FirstName: "Jo", CompanyName: "Acme Corp"}
+
+	assert.Equal(t, []userToken{
+		{attribute: "username", token: "jdoe"},
+		{attribute: "email", token: "john.doe"},
+		{attribute: "email", token: "john"},
+		{attribute: "email", token: "doe"},
+		{attribute: "company name", token: "acme corp"},
+		{attribute: "company name", token: "acme"},
+		{attribute: "company name", token: "corp"},
+	}, userTokens(user, 3), "the pieces shorter than 3 characters should be ignored")
+	assert.Empty(t, userTokens(UserInfo{}, 3))
+}
+
+// Tests the noUserInfo rule
+func TestNoUserInfo(t *testing.T) {
+	user := UserInfo{Username: "jdoe", Email: "john.doe@acme.com", FirstName: "John", LastName: "Doe", CompanyName: "Acme"}
+	tests := []struct {
+		password_input string
+		value          int
+		want_output    string
+	}{
+		{password_input: "JohnDoe2023!", value: 0, want_output: "john"},
+		{password_input: "x7#ACME!vLp9", value: 0, want_output: "acme"},
+		{password_input: "my-JDoe-pass", value: 0, want_output: "jdoe"},
+		{password_input: "x7#Qm!2vLp9@", value: 0, want_output: ""},
+		{password_input: "Doe@x7#Qm!2v", value: 4, want_output: ""},
+	}
+
+	for _, test := range tests {
+		result := userInfoRule{}.Check(test.password_input, RuleConfig{Rule: "noUserInfo", Value: test.value}, Options{User: user})
+		assert.Equal(t, test.want_output == "", result.Passed,
+			"it was expected that '%s' would contain the information '%s'", test.password_input, test.want_output)
+		assert.Equal(t, test.want_output, result.Detail)
+	}
+
+	assert.Equal(t, Result{
+		Rule:    "noUserInfo",
+		Actual:  4,
+		Passed:  false,
+		Message: "the password contains the first name of the user",
+		Detail:  "john",
+	}, userInfoRule{}.Check("John1!xQ", RuleConfig{Rule: "noUserInfo"}, Options{User: UserInfo{FirstName: "John"}}))
+	assert.True(t, userInfoRule{}.Check("JohnDoe2023!", RuleConfig{Rule: "noUserInfo"}, Options{}).Passed,
+		"without information about the user, every password should pass")
+}
@@ -0,0 +1,177 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+)
+
+// FileStore is a Store that keeps the policies and their history in a JSON file, so that they survive a restart of the server.
+// The policies are kept in memory and the whole file is written again after each change, which suits the
+// small number of policies of a server. It is safe for concurrent use, but the file must not be shared by
+// two servers.
+type FileStore struct {
+	mu     sync.Mutex // serializes the changes, so that each one is written to the file before the next one
+	path   string
+	memory *MemoryStore
+}
+
+// OpenFileStore opens the store kept in the given file, which is created with the first policy if it does
+// not exist.
+func OpenFileStore(path string) (*FileStore, error) {
+	memory, err := readPolicies(path)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{path: path, memory: memory}, nil
+}
+
+// contents of the file of a store: every version of each policy and the names of the deleted policies
+type storeFile struct {
+	History map[string][]*Policy `json:"history"`
+	Deleted []string             `json:"deleted,omitempty"`
+}
+
+// reads the policies kept in the file of a store, or no policy if the file does not exist
+func readPolicies(path string) (*MemoryStore, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewMemoryStore(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open the policies file: %w", err)
+	}
+
+	// the files written before the history was kept have the list of the current policies, which become the
+	// first version of their history
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var policies []*Policy
+		if err := json.Unmarshal(trimmed, &policies); err != nil {
+			return nil, fmt.Errorf("could not read the policies file %s: %w", path, err)
+		}
+		return NewMemoryStore(policies...), nil
+	}
+
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("could not read the policies file %s: %w", path, err)
+	}
+	memory := NewMemoryStore()
+	for name, versions := range file.History {
+		memory.versions[name] = versions
+	}
+	for _, name := range file.Deleted {
+		memory.deleted[name] = true
+	}
+	return memory, nil
+}
+
+// writes the policies to the file of the store. The file is replaced only once it is completely written, so
+// that a failure never leaves it half written.
+func (s *FileStore) write() error {
+	s.memory.mu.RLock()
+	file := storeFile{History: s.memory.versions}
+	for name := range s.memory.deleted {
+		file.Deleted = append(file.Deleted, name)
+	}
+	sort.Strings(file.Deleted)
+	data, err := json.MarshalIndent(file, "", "  ")
+	s.memory.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	temp := s.path + ".tmp"
+	if err := os.WriteFile(temp, data, 0o600); err != nil {
+		return fmt.Errorf("could not write the policies file: %w", err)
+	}
+	if err := os.Rename(temp, s.path); err != nil {
+		return fmt.Errorf("could not write the policies file: %w", err)
+	}
+	return nil
+}
+
+// applies a change to the policies and writes them to the file. If the file cannot be written, the change
+// is undone by reading the file again.
+func (s *FileStore) change(apply func() error) error {
+	if err := apply(); err != nil {
+		return err
+	}
+	if err := s.write(); err != nil {
+		if memory, read_err := readPolicies(s.path); read_err == nil {
+			s.memory = memory
+		}
+		return err
+	}
+	return nil
+}
+
+// List returns the current version of every policy, in the order of their names.
+func (s *FileStore) List() ([]*Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.memory.List()
+}
+
+// Get returns the current version of a policy.
+func (s *FileStore) Get(name string) (*Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.memory.Get(name)
+}
+
+// GetVersion returns a version of a policy.
+func (s *FileStore) GetVersion(name string, version int) (*Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.memory.GetVersion(name, version)
+}
+
+// History returns every version of a policy.
+func (s *FileStore) History(name string) ([]*Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.memory.History(name)
+}
+
+// Create adds a new policy.
+func (s *FileStore) Create(policy *Policy) (*Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var created *Policy
+	err := s.change(func() (err error) {
+		created, err = s.memory.Create(policy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Update adds a new version of a policy.
+func (s *FileStore) Update(policy *Policy) (*Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var updated *Policy
+	err := s.change(func() (err error) {
+		updated, err = s.memory.Update(policy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Delete removes a policy, keeping its history.
+func (s *FileStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.change(func() error { return s.memory.Delete(name) })
+}
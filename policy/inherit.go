@@ -0,0 +1,311 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"graphpass/password"
+	"graphpass/utils"
+	"strings"
+	"sync"
+)
+
+// rules that can appear several times in a policy, once for each parameter, since the parameter tells what the
+// rule counts or matches (e.g. minSpecialChars with two sets of special characters, or noKeyboardPattern with two
+// keyboard layouts). The other rules appear at most once in the effective rules of a policy.
+var repeatableRules = map[string]bool{
+	"minSpecialChars":   true,
+	"maxSpecialChars":   true,
+	"forbiddenChars":    true,
+	"matchesRegex":      true,
+	"notMatchesRegex":   true,
+	"noKeyboardPattern": true,
+}
+
+// rules whose lower values are stricter, besides the maximum rules: the longest run or sequence allowed, the
+// shortest keyboard walk or piece of user information looked up, and the number of breaches that rejects a
+// password
+var lowerIsStricter = map[string]bool{
+	"noRepeted":         true,
+	"noSequential":      true,
+	"noKeyboardPattern": true,
+	"noUserInfo":        true,
+	"notBreached":       true,
+}
+
+// the parameters that make a rule stricter, indexed by the name of the rule, which are kept when either the
+// rule of the policy or the rule of its base sets them
+var stricterParams = map[string]string{
+	"notCommon": password.NormalizeCommon,
+}
+
+// Lookup returns the given version of the policy with the given name, or its current version when the version
+// is 0, or ErrNotFound.
+type Lookup func(name string, version int) (*Policy, error)
+
+// Versions returns a Lookup of the versions of the policies of the store, so that the effective rules of a
+// policy are computed from the versions of the policies it extends that it is pinned to.
+func Versions(store Store) Lookup {
+	return func(name string, version int) (*Policy, error) {
+		if version == 0 {
+			return store.Get(name)
+		}
+		return store.GetVersion(name, version)
+	}
+}
+
+// returns a Lookup of the current versions of the policies of the store, whatever version is pinned, which
+// is how the effective rules of the policies will be once a change is applied and they are pinned again
+func currentVersions(store Store) Lookup {
+	return func(name string, _ int) (*Policy, error) {
+		return store.Get(name)
+	}
+}
+
+// tells if two rules configure the same rule, so that one overrides the other when they are merged
+func sameRule(a utils.Rule, b utils.Rule) bool {
+	return a.Rule == b.Rule && (!repeatableRules[a.Rule] || a.Param == b.Param)
+}
+
+// merges a rule of a policy into the same rule of the policy it extends: the strictest value is kept, which is
+// the highest value of a minimum rule and the lowest applied value of a maximum rule or of a rule in
+// lowerIsStricter, so that the policy never loosens a limit of its base. The parameter of the rule of the
+// policy replaces the one of the base (e.g. the class of firstCharClass), unless the parameter of the base is
+// in stricterParams.
+func mergeRule(base utils.Rule, override utils.Rule) utils.Rule {
+	if param, ok := stricterParams[override.Rule]; ok && base.Param == param {
+		override.Param = param
+	}
+	switch {
+	case strings.HasPrefix(override.Rule, "min") && base.Value > override.Value:
+		override.Value = base.Value
+	case strings.HasPrefix(override.Rule, "max") && base.Value < override.Value:
+		override.Value = base.Value
+	case lowerIsStricter[override.Rule] &&
+		password.AppliedValue(base.Rule, base.Value) < password.AppliedValue(override.Rule, override.Value):
+		override.Value = base.Value
+	}
+	return override
+}
+
+// MergeRules merges the rules of a policy into the rules of the policy it extends. A rule of the policy that
+// is not in the base is added after the rules of the base, and a rule that is in the base replaces it in its
+// position, keeping the strictest value of the two rules as in mergeRule. The rules whose parameter tells what
+// they count or match (minSpecialChars, maxSpecialChars, forbiddenChars, matchesRegex, notMatchesRegex and
+// noKeyboardPattern) are only merged with the rules with the same parameter.
+func MergeRules(base []utils.Rule, overrides []utils.Rule) []utils.Rule {
+	merged := append([]utils.Rule(nil), base...)
+	for _, override := range overrides {
+		found := false
+		for i, rule := range merged {
+			if sameRule(rule, override) {
+				merged[i] = mergeRule(rule, override)
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, override)
+		}
+	}
+	return merged
+}
+
+// returns the policy followed by the policies it extends, up to the policy that extends no other
+func chain(policy *Policy, lookup Lookup) ([]*Policy, error) {
+	policies := []*Policy{policy}
+	seen := map[string]bool{policy.Name: true}
+	for current := policy; current.Extends != ""; {
+		if seen[current.Extends] {
+			return nil, fmt.Errorf("the policy '%s' extends itself through the policy '%s'", current.Extends, current.Name)
+		}
+		base, err := lookup(current.Extends, current.BaseVersion)
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("the policy '%s' extends the policy '%s', which does not exist", current.Name, current.Extends)
+		}
+		if err != nil {
+			return nil, err
+		}
+		seen[base.Name] = true
+		policies = append(policies, base)
+		current = base
+	}
+	return policies, nil
+}
+
+// Effective returns the effective rules of the policy: the rules of the policies it extends, from the policy
+// that extends no other, merged with the rules of the policy by MergeRules. Each policy it extends is looked up
+// at the version pinned by the policy that extends it (see BaseVersion), so that with Versions the effective
+// rules of a version of a policy never change.
+func Effective(policy *Policy, lookup Lookup) ([]utils.Rule, error) {
+	policies, err := chain(policy, lookup)
+	if err != nil {
+		return nil, err
+	}
+	var rules []utils.Rule
+	for i := len(policies) - 1; i >= 0; i-- {
+		rules = MergeRules(rules, policies[i].Rules)
+	}
+	return rules, nil
+}
+
+// verifies that the policy has effective rules and that they are valid together, e.g. that a maximum rule of
+// the policy is not below a minimum rule of the policy it extends
+func checkEffective(policy *Policy, lookup Lookup) error {
+	rules, err := Effective(policy, lookup)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("the policy '%s' has no rules", policy.Name)
+	}
+	if err := utils.CheckRules(rules); err != nil {
+		return fmt.Errorf("the effective rules of the policy '%s' are invalid: %w", policy.Name, err)
+	}
+	return nil
+}
+
+// CheckChange verifies, before a policy is created or updated in the store, that its effective rules are
+// valid, and that the effective rules of the policies of the store that extend it remain valid once they
+// are pinned to the new version of the policy, as Update does.
+func CheckChange(store Store, changed *Policy) error {
+	lookup := func(name string, _ int) (*Policy, error) {
+		if name == changed.Name {
+			return changed, nil
+		}
+		return store.Get(name)
+	}
+	if err := checkEffective(changed, lookup); err != nil {
+		return err
+	}
+
+	policies, err := store.List()
+	if err != nil {
+		return err
+	}
+	for _, other := range policies {
+		if other.Name == changed.Name || other.Extends == "" {
+			continue
+		}
+		// the policies whose chain is already broken by another policy are not affected by the change
+		extended, err := chain(other, lookup)
+		if err != nil {
+			continue
+		}
+		for _, base := range extended[1:] {
+			if base.Name != changed.Name {
+				continue
+			}
+			if err := checkEffective(other, lookup); err != nil {
+				return fmt.Errorf("the change would invalidate the policy '%s', which extends the policy '%s': %w",
+					other.Name, changed.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckDelete verifies, before a policy is deleted from the store, that no policy of the store extends it.
+func CheckDelete(store Store, name string) error {
+	policies, err := store.List()
+	if err != nil {
+		return err
+	}
+	for _, other := range policies {
+		if other.Extends == name {
+			return fmt.Errorf("the policy '%s' cannot be deleted, since it is extended by the policy '%s'", name, other.Name)
+		}
+	}
+	return nil
+}
+
+// serializes the changes made by Create, Update and Delete, so that a change is never checked against policies
+// that a concurrent change is replacing. It does not serialize the servers that share a SQL store, whose
+// concurrent changes of related policies can still leave invalid effective rules.
+var changes sync.Mutex
+
+// pins the policy to the current version of the policy it extends, if any
+func pin(store Store, policy *Policy) error {
+	if policy.Extends == "" {
+		policy.BaseVersion = 0
+		return nil
+	}
+	base, err := store.Get(policy.Extends)
+	if err != nil {
+		return err
+	}
+	policy.BaseVersion = base.Version
+	return nil
+}
+
+// Create adds a new policy to the store after CheckChange, pinned to the current version of the policy it
+// extends. It returns the created version, or ErrExists.
+func Create(store Store, created *Policy) (*Policy, error) {
+	changes.Lock()
+	defer changes.Unlock()
+	if err := CheckChange(store, created); err != nil {
+		return nil, err
+	}
+	pinned := clonePolicy(created)
+	if err := pin(store, pinned); err != nil {
+		return nil, err
+	}
+	return store.Create(pinned)
+}
+
+// Update adds a new version of a policy to the store after CheckChange, pinned to the current version of the
+// policy it extends, and a new version of each policy that extends it, pinned to its new version, by the same
+// author, so that the change applies to them while their previous versions keep their effective rules. It
+// returns the new version, or ErrNotFound.
+func Update(store Store, changed *Policy) (*Policy, error) {
+	changes.Lock()
+	defer changes.Unlock()
+	if err := CheckChange(store, changed); err != nil {
+		return nil, err
+	}
+	pinned := clonePolicy(changed)
+	if err := pin(store, pinned); err != nil {
+		return nil, err
+	}
+	updated, err := store.Update(pinned)
+	if err != nil {
+		return nil, err
+	}
+	if err := rebase(store, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Delete removes a policy from the store after CheckDelete, keeping its history, or returns ErrNotFound.
+func Delete(store Store, name string) error {
+	changes.Lock()
+	defer changes.Unlock()
+	if err := CheckDelete(store, name); err != nil {
+		return err
+	}
+	return store.Delete(name)
+}
+
+// adds a new version of each policy that extends the given version of a policy, pinned to it, and so on for
+// the policies that extend them
+func rebase(store Store, base *Policy) error {
+	policies, err := store.List()
+	if err != nil {
+		return err
+	}
+	for _, other := range policies {
+		if other.Extends != base.Name {
+			continue
+		}
+		other.BaseVersion = base.Version
+		other.Author, other.UpdatedAt = base.Author, base.UpdatedAt
+		rebased, err := store.Update(other)
+		if err != nil {
+			return err
+		}
+		if err := rebase(store, rebased); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+// unit tests to the inheritance of the policies
+
+package policy
+
+import (
+	"graphpass/utils"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests the merging of the rules of a policy into the rules of its base
+func TestMergeRules(t *testing.T) {
+	base := []utils.Rule{
+		{Rule: "minSize", Value: 12},
+		{Rule: "maxSize", Value: 64},
+		{Rule: "minSpecialChars", Value: 1},
+		{Rule: "noRepeted", Value: 3},
+		{Rule: "forbiddenChars", Param: " "},
+	}
+
+	tests := []struct {
+		name        string
+		overrides   []utils.Rule
+		want_output []utils.Rule
+	}{
+		{
+			name:        "the highest minimum and the lowest maximum are kept",
+			overrides:   []utils.Rule{{Rule: "minSize", Value: 8}, {Rule: "maxSize", Value: 32}},
+			want_output: []utils.Rule{{Rule: "minSize", Value: 12}, {Rule: "maxSize", Value: 32}, base[2], base[3], base[4]},
+		},
+		{
+			name:        "a stricter minimum replaces the minimum of the base",
+			overrides:   []utils.Rule{{Rule: "minSize", Value: 16}},
+			want_output: []utils.Rule{{Rule: "minSize", Value: 16}, base[1], base[2], base[3], base[4]},
+		},
+		{
+			name:        "a lower value of noRepeted is stricter",
+			overrides:   []utils.Rule{{Rule: "noRepeted", Value: 2}},
+			want_output: []utils.Rule{base[0], base[1], base[2], {Rule: "noRepeted", Value: 2}, base[4]},
+		},
+		{
+			name:        "a looser noRepeted keeps the value of the base",
+			overrides:   []utils.Rule{{Rule: "noRepeted", Value: 5}},
+			want_output: base,
+		},
+		{
+			name:        "the other rules are replaced",
+			overrides:   []utils.Rule{{Rule: "firstCharClass", Param: "upper"}},
+			want_output: append(append([]utils.Rule(nil), base...), utils.Rule{Rule: "firstCharClass", Param: "upper"}),
+		},
+		{
+			name:        "new rules are added after the rules of the base",
+			overrides:   []utils.Rule{{Rule: "minDigit", Value: 2}},
+			want_output: append(append([]utils.Rule(nil), base...), utils.Rule{Rule: "minDigit", Value: 2}),
+		},
+		{
+			name:      "rules with another parameter are added when the parameter tells what they count",
+			overrides: []utils.Rule{{Rule: "minSpecialChars", Value: 2, Param: "@#"}, {Rule: "forbiddenChars", Param: "\\\\"}},
+			want_output: append(append([]utils.Rule(nil), base...),
+				utils.Rule{Rule: "minSpecialChars", Value: 2, Param: "@#"}, utils.Rule{Rule: "forbiddenChars", Param: "\\\\"}),
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.want_output, MergeRules(base, test.overrides), test.name)
+	}
+	assert.Equal(t, 12, base[0].Value, "the rules of the base should not be changed")
+}
+
+// Tests that the rules whose lower values are stricter keep the strictest value, with the value 0 standing for
+// the default of the rule
+func TestMergeRulesWithLowerIsStricter(t *testing.T) {
+	tests := []struct {
+		base        utils.Rule
+		override    utils.Rule
+		want_output int
+	}{
+		{base: utils.Rule{Rule: "noRepeted", Value: 2}, override: utils.Rule{Rule: "noRepeted", Value: 0}, want_output: 0},
+		{base: utils.Rule{Rule: "noRepeted", Value: 0}, override: utils.Rule{Rule: "noRepeted", Value: 3}, want_output: 0},
+		{base: utils.Rule{Rule: "noSequential", Value: 0}, override: utils.Rule{Rule: "noSequential", Value: 4}, want_output: 0},
+		{base: utils.Rule{Rule: "noSequential", Value: 3}, override: utils.Rule{Rule: "noSequential", Value: 0}, want_output: 0},
+		{base: utils.Rule{Rule: "noKeyboardPattern", Value: 0}, override: utils.Rule{Rule: "noKeyboardPattern", Value: 6}, want_output: 0},
+		{base: utils.Rule{Rule: "noKeyboardPattern", Value: 3}, override: utils.Rule{Rule: "noKeyboardPattern", Value: 0}, want_output: 3},
+		{base: utils.Rule{Rule: "noUserInfo", Value: 0}, override: utils.Rule{Rule: "noUserInfo", Value: 5}, want_output: 0},
+		{base: utils.Rule{Rule: "noUserInfo", Value: 4}, override: utils.Rule{Rule: "noUserInfo", Value: 2}, want_output: 2},
+		{base: utils.Rule{Rule: "notBreached", Value: 0}, override: utils.Rule{Rule: "notBreached", Value: 1000}, want_output: 0},
+		{base: utils.Rule{Rule: "notBreached", Value: 10}, override: utils.Rule{Rule: "notBreached", Value: 0}, want_output: 0},
+	}
+
+	for _, test := range tests {
+		merged := MergeRules([]utils.Rule{test.base}, []utils.Rule{test.override})
+		require.Len(t, merged, 1)
+		assert.Equal(t, test.want_output, merged[0].Value,
+			"merging %v into %v should keep the value %d", test.override, test.base, test.want_output)
+	}
+
+	// the normalization of the notCommon rule is kept when either the policy or its base sets it
+	normalized := utils.Rule{Rule: "notCommon", Param: "normalize"}
+	assert.Equal(t, []utils.Rule{normalized}, MergeRules([]utils.Rule{normalized}, []utils.Rule{{Rule: "notCommon"}}))
+	assert.Equal(t, []utils.Rule{normalized}, MergeRules([]utils.Rule{{Rule: "notCommon"}}, []utils.Rule{normalized}))
+
+	// each keyboard layout is merged on its own, so a policy cannot narrow the layouts checked by its base
+	base := []utils.Rule{{Rule: "noKeyboardPattern", Value: 4}}
+	overrides := []utils.Rule{{Rule: "noKeyboardPattern", Value: 5, Param: "qwerty"}}
+	assert.Equal(t, append(base, overrides...), MergeRules(base, overrides))
+}
+
+// Tests the effective rules of a chain of policies
+func TestEffective(t *testing.T) {
+	store := NewMemoryStore(
+		&Policy{Name: "corporate", Version: 1, Rules: []utils.Rule{{Rule: "minSize", Value: 12}, {Rule: "maxSize", Value: 64}}},
+		&Policy{Name: "finance", Version: 1, Extends: "corporate", Rules: []utils.Rule{{Rule: "minSize", Value: 14}}},
+		&Policy{Name: "treasury", Version: 1, Extends: "finance", Rules: []utils.Rule{{Rule: "minDigit", Value: 2}}},
+		&Policy{Name: "orphan", Version: 1, Extends: "missing"},
+		&Policy{Name: "ping", Version: 1, Extends: "pong"},
+		&Policy{Name: "pong", Version: 1, Extends: "ping"},
+	)
+
+	treasury, _ := store.Get("treasury")
+	rules, err := Effective(treasury, Versions(store))
+	require.Nil(t, err)
+	assert.Equal(t, []utils.Rule{{Rule: "minSize", Value: 14}, {Rule: "maxSize", Value: 64}, {Rule: "minDigit", Value: 2}}, rules)
+
+	orphan, _ := store.Get("orphan")
+	_, err = Effective(orphan, Versions(store))
+	assert.ErrorContains(t, err, "extends the policy 'missing', which does not exist")
+
+	ping, _ := store.Get("ping")
+	_, err = Effective(ping, Versions(store))
+	assert.ErrorContains(t, err, "extends itself")
+}
+
+// Tests the checks of the changes of policies extended by other policies
+func TestCheckChange(t *testing.T) {
+	store := NewMemoryStore(
+		&Policy{Name: "corporate", Version: 1, Rules: []utils.Rule{{Rule: "minSize", Value: 12}}},
+		&Policy{Name: "finance", Version: 1, Extends: "corporate", Rules: []utils.Rule{{Rule: "maxSize", Value: 20}}},
+	)
+
+	assert.Nil(t, CheckChange(store, &Policy{Name: "corporate", Rules: []utils.Rule{{Rule: "minSize", Value: 16}}}))
+	assert.ErrorContains(t, CheckChange(store, &Policy{Name: "corporate", Rules: []utils.Rule{{Rule: "minSize", Value: 24}}}),
+		"would invalidate the policy 'finance'", "the base should not raise a minimum above a maximum of the policies extending it")
+	assert.ErrorContains(t, CheckChange(store, &Policy{Name: "retail", Extends: "corporate", Rules: []utils.Rule{{Rule: "maxSize", Value: 8}}}),
+		"the effective rules of the policy 'retail' are invalid")
+	assert.ErrorContains(t, CheckChange(store, &Policy{Name: "corporate", Extends: "finance", Rules: []utils.Rule{{Rule: "minSize", Value: 12}}}),
+		"extends itself")
+	assert.ErrorContains(t, CheckChange(store, &Policy{Name: "retail", Extends: "missing"}), "does not exist")
+
+	assert.ErrorContains(t, CheckDelete(store, "corporate"), "extended by the policy 'finance'")
+	assert.Nil(t, CheckDelete(store, "finance"))
+}
+
+// Tests that the versions of a policy keep the effective rules of the version of the policy they extend
+func TestUpdatePinsBaseVersions(t *testing.T) {
+	store := NewMemoryStore()
+	corporate := &Policy{Name: "corporate", Version: 1, Rules: []utils.Rule{{Rule: "minSize", Value: 12}}}
+	_, err := Create(store, corporate)
+	require.Nil(t, err)
+	finance, err := Create(store, &Policy{Name: "finance", Version: 1, Extends: "corporate", Rules: []utils.Rule{{Rule: "minDigit", Value: 2}}})
+	require.Nil(t, err)
+	assert.Equal(t, 1, finance.BaseVersion)
+	_, err = Create(store, &Policy{Name: "treasury", Version: 1, Extends: "finance"})
+	require.Nil(t, err)
+
+	_, err = Update(store, &Policy{Name: "corporate", Rules: []utils.Rule{{Rule: "minSize", Value: 16}}, Author: "alice"})
+	require.Nil(t, err)
+
+	// the policies that extend the base, directly or not, get a new version pinned to the new version
+	finance, err = store.Get("finance")
+	require.Nil(t, err)
+	assert.Equal(t, 2, finance.Version)
+	assert.Equal(t, 2, finance.BaseVersion)
+	assert.Equal(t, "alice", finance.Author)
+	treasury, err := store.Get("treasury")
+	require.Nil(t, err)
+	assert.Equal(t, 2, treasury.Version)
+	assert.Equal(t, 2, treasury.BaseVersion)
+
+	rules, err := Effective(treasury, Versions(store))
+	require.Nil(t, err)
+	assert.Equal(t, []utils.Rule{{Rule: "minSize", Value: 16}, {Rule: "minDigit", Value: 2}}, rules)
+
+	// the previous versions keep the rules in force when they were current
+	previous, err := store.GetVersion("treasury", 1)
+	require.Nil(t, err)
+	rules, err = Effective(previous, Versions(store))
+	require.Nil(t, err)
+	assert.Equal(t, []utils.Rule{{Rule: "minSize", Value: 12}, {Rule: "minDigit", Value: 2}}, rules)
+
+	_, err = Update(store, &Policy{Name: "corporate", Rules: []utils.Rule{{Rule: "maxSize", Value: 8}, {Rule: "minSize", Value: 12}}})
+	assert.ErrorContains(t, err, "the effective rules of the policy")
+	_, err = Update(store, &Policy{Name: "missing", Rules: corporate.Rules})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// Tests that concurrent changes, each valid on its own, cannot leave effective rules that are invalid together
+func TestConcurrentChanges(t *testing.T) {
+	store := NewMemoryStore()
+	_, err := Create(store, &Policy{Name: "corporate", Version: 1, Rules: []utils.Rule{{Rule: "minSize", Value: 12}}})
+	require.Nil(t, err)
+	_, err = Create(store, &Policy{Name: "finance", Version: 1, Extends: "corporate", Rules: []utils.Rule{{Rule: "maxSize", Value: 24}}})
+	require.Nil(t, err)
+
+	var wait sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wait.Add(2)
+		go func() {
+			defer wait.Done()
+			Update(store, &Policy{Name: "corporate", Rules: []utils.Rule{{Rule: "minSize", Value: 20}}})
+		}()
+		go func() {
+			defer wait.Done()
+			Update(store, &Policy{Name: "finance", Extends: "corporate", Rules: []utils.Rule{{Rule: "maxSize", Value: 16}}})
+		}()
+	}
+	wait.Wait()
+
+	finance, err := store.Get("finance")
+	require.Nil(t, err)
+	assert.Nil(t, checkEffective(finance, Versions(store)))
+
+	assert.ErrorContains(t, Delete(store, "corporate"), "extended by the policy 'finance'")
+	assert.ErrorIs(t, Delete(store, "missing"), ErrNotFound)
+}
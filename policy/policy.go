@@ -0,0 +1,139 @@
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"graphpass/utils"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// the name of a policy is used to choose it in the verify query, so it is restricted to letters, digits,
+// dots, hyphens and underscores (e.g. "corporate-v3")
+var validName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Policy is a named set of rules defined on the server, so that the services that verify passwords choose
+// the policy by its name instead of sending the rules in every request. In a YAML file, a policy has the format:
+//
+//	name: corporate
+//	version: 3
+//	rules:
+//	  - {rule: minSize, value: 12}
+//	  - {rule: minSpecialChars, value: 1, param: owasp}
+//
+// A policy can extend another policy by its name, with "extends: corporate", so that its rules override or
+// are added to the rules of the other policy (see Effective).
+type Policy struct {
+	Name    string       `yaml:"name" json:"name"`
+	Version int          `yaml:"version" json:"version"`
+	Extends string       `yaml:"extends" json:"extends,omitempty"`
+	Rules   []utils.Rule `yaml:"rules" json:"rules"`
+	// BaseVersion is the version of the extended policy that this version is merged with, pinned when the
+	// version is stored, so that the effective rules of a version never change. A change to the extended policy
+	// adds a new version of this policy, pinned to the new version (see Update).
+	BaseVersion int `yaml:"-" json:"baseVersion,omitempty"`
+	// Author is who created this version of the policy, if known. The policies of a directory have no author.
+	Author string `yaml:"-" json:"author,omitempty"`
+	// UpdatedAt is when this version of the policy was created, from when its rules were in force.
+	UpdatedAt time.Time `yaml:"-" json:"updatedAt"`
+}
+
+// Validate verifies that the policy has a valid name and version, and at least one rule unless it extends
+// another policy. The rules are checked in the same way as the rules received in a request. The policy it
+// extends is not looked up, which is done by CheckChange.
+func (p *Policy) Validate() error {
+	if !validName.MatchString(p.Name) {
+		return fmt.Errorf("the name '%s' of the policy is invalid. It must have only letters, digits, '.', '-' and '_'", p.Name)
+	}
+	if p.Version < 1 {
+		return fmt.Errorf("the version %d of the policy '%s' is invalid. It must be positive", p.Version, p.Name)
+	}
+	if p.Extends != "" && (!validName.MatchString(p.Extends) || p.Extends == p.Name) {
+		return fmt.Errorf("the policy '%s' cannot extend the policy '%s'", p.Name, p.Extends)
+	}
+	if len(p.Rules) == 0 && p.Extends == "" {
+		return fmt.Errorf("the policy '%s' has no rules", p.Name)
+	}
+	if err := utils.CheckRules(p.Rules); err != nil {
+		return fmt.Errorf("the policy '%s' is invalid: %w", p.Name, err)
+	}
+	return nil
+}
+
+// Parse reads a policy in the YAML format. A policy without a version is at version 1. Unknown fields are
+// rejected, since they are most likely a misspelled field.
+func Parse(data []byte) (*Policy, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	policy := &Policy{}
+	if err := decoder.Decode(policy); err != nil {
+		return nil, err
+	}
+	if policy.Version == 0 {
+		policy.Version = 1
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// LoadFile reads a policy from a YAML file.
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the policy: %w", err)
+	}
+	policy, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the policy %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// LoadDir reads every policy of a directory, one per file with the extension .yaml or .yml, in the order
+// of the names of the files. Two policies cannot have the same name, and a policy can only extend a policy
+// of the same directory.
+func LoadDir(dir string) ([]*Policy, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("could not open the policies directory: %w", err)
+	}
+
+	var paths []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+
+	policies := make([]*Policy, 0, len(paths))
+	files := map[string]string{} // file of each policy, indexed by the name of the policy
+	for _, path := range paths {
+		policy, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if file, exists := files[policy.Name]; exists {
+			return nil, fmt.Errorf("the policy '%s' is defined in both %s and %s", policy.Name, file, path)
+		}
+		files[policy.Name] = path
+		policies = append(policies, policy)
+	}
+
+	loaded := NewMemoryStore(policies...)
+	for _, policy := range policies {
+		if err := checkEffective(policy, currentVersions(loaded)); err != nil {
+			return nil, fmt.Errorf("could not read the policy %s: %w", files[policy.Name], err)
+		}
+	}
+	return policies, nil
+}
@@ -0,0 +1,117 @@
+// unit tests to the loading of the policies
+
+package policy
+
+import (
+	"graphpass/utils"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writes a policy file in the directory
+func writePolicy(t *testing.T, dir string, file string, content string) {
+	require.Nil(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0o600))
+}
+
+// Tests the parsing of a policy
+func TestParse(t *testing.T) {
+	policy, err := Parse([]byte(`
+name: corporate-v3
+version: 3
+rules:
+  - {rule: minSize, value: 12}
+  - {rule: minSpecialChars, value: 1, param: owasp}
+`))
+
+	require.Nil(t, err)
+	assert.Equal(t, &Policy{
+		Name:    "corporate-v3",
+		Version: 3,
+		Rules: []utils.Rule{
+			{Rule: "minSize", Value: 12},
+			{Rule: "minSpecialChars", Value: 1, Param: "owasp"},
+		},
+	}, policy)
+
+	policy, err = Parse([]byte("name: basic\nrules:\n  - {rule: minSize, value: 8}\n"))
+	require.Nil(t, err)
+	assert.Equal(t, 1, policy.Version, "a policy without a version should be at version 1")
+
+	policy, err = Parse([]byte("name: finance\nextends: corporate\n"))
+	require.Nil(t, err)
+	assert.Equal(t, "corporate", policy.Extends, "a policy that extends another should not need rules")
+
+	invalid := []string{
+		"name: basic\nrules: []\n",
+		"name: 'a b'\nrules:\n  - {rule: minSize, value: 8}\n",
+		"name: basic\nrules:\n  - {rule: minSiz, value: 8}\n",
+		"name: basic\nrules:\n  - {rule: minSize, value: -1}\n",
+		"name: basic\nrules:\n  - {rule: maxSize, value: 6}\n  - {rule: minSize, value: 8}\n",
+		"name: basic\nrule:\n  - {rule: minSize, value: 8}\n",
+		"name: basic\nversion: -1\nrules:\n  - {rule: minSize, value: 8}\n",
+		"name: basic\nextends: basic\n",
+		"name: basic\nextends: 'a b'\nrules:\n  - {rule: minSize, value: 8}\n",
+	}
+	for _, content := range invalid {
+		_, err := Parse([]byte(content))
+		assert.NotNil(t, err, "the policy should be invalid:\n%s", content)
+	}
+}
+
+// Tests the loading of the policies of a directory
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	writePolicy(t, dir, "basic.yaml", "name: basic\nrules:\n  - {rule: minSize, value: 8}\n")
+	writePolicy(t, dir, "corporate.yml", "name: corporate\nrules:\n  - {rule: minSize, value: 12}\n")
+	writePolicy(t, dir, "README.md", "not a policy")
+
+	policies, err := LoadDir(dir)
+
+	require.Nil(t, err)
+	require.Len(t, policies, 2)
+	assert.Equal(t, "basic", policies[0].Name)
+	assert.Equal(t, "corporate", policies[1].Name)
+
+	writePolicy(t, dir, "copy.yaml", "name: basic\nrules:\n  - {rule: minSize, value: 10}\n")
+	_, err = LoadDir(dir)
+	assert.NotNil(t, err, "two policies with the same name should not be accepted")
+
+	_, err = LoadDir(filepath.Join(dir, "missing"))
+	assert.NotNil(t, err)
+}
+
+// Tests the loading of policies that extend other policies of the directory
+func TestLoadDirWithExtends(t *testing.T) {
+	dir := t.TempDir()
+	writePolicy(t, dir, "corporate.yaml", "name: corporate\nrules:\n  - {rule: minSize, value: 12}\n")
+	writePolicy(t, dir, "finance.yaml", "name: finance\nextends: corporate\nrules:\n  - {rule: minDigit, value: 2}\n")
+
+	policies, err := LoadDir(dir)
+	require.Nil(t, err)
+	assert.Len(t, policies, 2)
+
+	invalid := map[string]string{
+		"a missing policy":   "name: finance\nextends: missing\n",
+		"a cycle":            "name: finance\nextends: loop\n",
+		"conflicting bounds": "name: finance\nextends: corporate\nrules:\n  - {rule: maxSize, value: 10}\n",
+	}
+	for name, content := range invalid {
+		writePolicy(t, dir, "finance.yaml", content)
+		writePolicy(t, dir, "loop.yaml", "name: loop\nextends: finance\n")
+		_, err := LoadDir(dir)
+		assert.NotNil(t, err, "a policy that extends %s should not be accepted", name)
+		require.Nil(t, os.Remove(filepath.Join(dir, "loop.yaml")))
+	}
+}
+
+// Tests the example policies distributed with the server
+func TestLoadExamplePolicies(t *testing.T) {
+	policies, err := LoadDir(filepath.Join("..", "policies"))
+
+	require.Nil(t, err)
+	assert.NotEmpty(t, policies)
+}
@@ -0,0 +1,271 @@
+package policy
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// statements that create the table of the current policies and the table of every version of the policies,
+// whose rules are kept as JSON. The policies created before the history was kept are copied to it, without
+// author nor time of update.
+var createPoliciesTables = []string{
+	`CREATE TABLE IF NOT EXISTS policies (
+	name    VARCHAR(255) PRIMARY KEY,
+	version INTEGER NOT NULL,
+	rules   TEXT NOT NULL
+)`,
+	`CREATE TABLE IF NOT EXISTS policy_versions (
+	name         VARCHAR(255) NOT NULL,
+	version      INTEGER NOT NULL,
+	rules        TEXT NOT NULL,
+	extends      VARCHAR(255) NOT NULL DEFAULT '',
+	base_version INTEGER NOT NULL DEFAULT 0,
+	author       VARCHAR(255) NOT NULL,
+	updated_at   VARCHAR(64) NOT NULL,
+	PRIMARY KEY (name, version)
+)`,
+	`INSERT INTO policy_versions (name, version, rules, author, updated_at)
+	SELECT name, version, rules, '', '' FROM policies p
+	WHERE NOT EXISTS (SELECT 1 FROM policy_versions v WHERE v.name = p.name AND v.version = p.version)`,
+}
+
+// columns added to the table of versions after it was first created, with their definitions, which are added
+// to the tables created before them
+var addedVersionColumns = []struct {
+	name       string
+	definition string
+}{
+	{name: "extends", definition: "VARCHAR(255) NOT NULL DEFAULT ''"},
+	{name: "base_version", definition: "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// columns of a policy read from the table of versions
+const versionColumns = "v.name, v.version, v.extends, v.base_version, v.rules, v.author, v.updated_at"
+
+// query of the current version of the policies, to be completed by a condition or an order
+const currentQuery = "SELECT " + versionColumns +
+	" FROM policies p JOIN policy_versions v ON v.name = p.name AND v.version = p.version"
+
+// SQLStore is a Store that keeps the policies and their history in a SQL database, through database/sql, so
+// that they can be shared by several servers. The statements use "?" placeholders, as SQLite and MySQL do.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore creates a store in the given database, creating the tables of policies if they do not exist and
+// adding the columns missing from the tables created by previous versions of the server.
+func NewSQLStore(db *sql.DB) (*SQLStore, error) {
+	for _, statement := range createPoliciesTables {
+		if _, err := db.Exec(statement); err != nil {
+			return nil, fmt.Errorf("could not create the tables of policies: %w", err)
+		}
+	}
+	for _, column := range addedVersionColumns {
+		// selecting a missing column fails, whatever the database
+		rows, err := db.Query("SELECT " + column.name + " FROM policy_versions WHERE 1 = 0")
+		if err == nil {
+			rows.Close()
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE policy_versions ADD COLUMN " + column.name + " " + column.definition); err != nil {
+			return nil, fmt.Errorf("could not add the column %s to the table of policies: %w", column.name, err)
+		}
+	}
+	return &SQLStore{db: db}, nil
+}
+
+// something a policy can be scanned from, either a single row or a row of many
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// reads a policy from a row with its name, version, extended policy and its version, rules, author and time
+// of update
+func scanPolicy(row scanner) (*Policy, error) {
+	var policy Policy
+	var rules, updated_at string
+	if err := row.Scan(&policy.Name, &policy.Version, &policy.Extends, &policy.BaseVersion, &rules, &policy.Author, &updated_at); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(rules), &policy.Rules); err != nil {
+		return nil, fmt.Errorf("could not read the rules of the policy '%s': %w", policy.Name, err)
+	}
+	if updated_at != "" {
+		var err error
+		if policy.UpdatedAt, err = time.Parse(time.RFC3339Nano, updated_at); err != nil {
+			return nil, fmt.Errorf("could not read the time of update of the policy '%s': %w", policy.Name, err)
+		}
+	}
+	return &policy, nil
+}
+
+// runs a query of policies and reads them
+func (s *SQLStore) queryPolicies(query string, args ...any) ([]*Policy, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []*Policy{}
+	for rows.Next() {
+		policy, err := scanPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, rows.Err()
+}
+
+// runs a query of a single policy and reads it, or returns ErrNotFound
+func (s *SQLStore) queryPolicy(query string, args ...any) (*Policy, error) {
+	policy, err := scanPolicy(s.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return policy, err
+}
+
+// List returns the current version of every policy, in the order of their names.
+func (s *SQLStore) List() ([]*Policy, error) {
+	return s.queryPolicies(currentQuery + " ORDER BY v.name")
+}
+
+// Get returns the current version of a policy.
+func (s *SQLStore) Get(name string) (*Policy, error) {
+	return s.queryPolicy(currentQuery+" WHERE p.name = ?", name)
+}
+
+// GetVersion returns a version of a policy.
+func (s *SQLStore) GetVersion(name string, version int) (*Policy, error) {
+	return s.queryPolicy("SELECT "+versionColumns+" FROM policy_versions v WHERE v.name = ? AND v.version = ?", name, version)
+}
+
+// History returns every version of a policy.
+func (s *SQLStore) History(name string) ([]*Policy, error) {
+	history, err := s.queryPolicies("SELECT "+versionColumns+" FROM policy_versions v WHERE v.name = ? ORDER BY v.version", name)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, ErrNotFound
+	}
+	return history, nil
+}
+
+// runs a function in a transaction, which is committed if the function succeeds and rolled back otherwise
+func (s *SQLStore) inTransaction(run func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := run(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// adds a version of a policy to the history and makes it the current version
+func insertVersion(tx *sql.Tx, policy *Policy, current bool) error {
+	rules, err := json.Marshal(policy.Rules)
+	if err != nil {
+		return err
+	}
+	updated_at := ""
+	if !policy.UpdatedAt.IsZero() {
+		updated_at = policy.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	_, err = tx.Exec("INSERT INTO policy_versions (name, version, extends, base_version, rules, author, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		policy.Name, policy.Version, policy.Extends, policy.BaseVersion, string(rules), policy.Author, updated_at)
+	if err != nil {
+		return err
+	}
+	if current {
+		_, err = tx.Exec("UPDATE policies SET version = ?, rules = ? WHERE name = ?", policy.Version, string(rules), policy.Name)
+	} else {
+		_, err = tx.Exec("INSERT INTO policies (name, version, rules) VALUES (?, ?, ?)", policy.Name, policy.Version, string(rules))
+	}
+	return err
+}
+
+// tells if an error of the database is the violation of a unique constraint, as reported by SQLite ("UNIQUE
+// constraint failed") and MySQL ("Duplicate entry")
+func isUniqueViolation(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "unique constraint") || strings.Contains(message, "duplicate entry")
+}
+
+// Create adds a new policy. A policy created by another server between the check of the name and the
+// insertion violates the primary key of the tables, which is reported as ErrExists.
+func (s *SQLStore) Create(policy *Policy) (*Policy, error) {
+	created := clonePolicy(policy)
+	err := s.inTransaction(func(tx *sql.Tx) error {
+		var count int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM policies WHERE name = ?", policy.Name).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrExists
+		}
+
+		// continues the history of a deleted policy with the same name
+		var last sql.NullInt64
+		if err := tx.QueryRow("SELECT MAX(version) FROM policy_versions WHERE name = ?", policy.Name).Scan(&last); err != nil {
+			return err
+		}
+		if last.Valid && int(last.Int64) >= created.Version {
+			created.Version = int(last.Int64) + 1
+		}
+		return insertVersion(tx, created, false)
+	})
+	if err != nil && isUniqueViolation(err) {
+		return nil, ErrExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Update adds a new version of a policy.
+func (s *SQLStore) Update(policy *Policy) (*Policy, error) {
+	updated := clonePolicy(policy)
+	err := s.inTransaction(func(tx *sql.Tx) error {
+		err := tx.QueryRow("SELECT version FROM policies WHERE name = ?", policy.Name).Scan(&updated.Version)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		updated.Version++
+		return insertVersion(tx, updated, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Delete removes a policy, keeping its history.
+func (s *SQLStore) Delete(name string) error {
+	result, err := s.db.Exec("DELETE FROM policies WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
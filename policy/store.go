@@ -0,0 +1,237 @@
+package policy
+
+import (
+	"errors"
+	"graphpass/utils"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Errors returned by the stores of policies.
+var (
+	// ErrNotFound is returned when there is no policy with the requested name, or no version of a policy with
+	// the requested number.
+	ErrNotFound = errors.New("policy not found")
+	// ErrExists is returned when a policy is created with the name of an existing policy.
+	ErrExists = errors.New("policy already exists")
+)
+
+// Store is implemented by the stores of policies, which keep the policies that can be chosen in the verify
+// query and that are managed through the API. Every version of a policy is kept, even after the policy is
+// deleted, so that it is always possible to tell which rules were in force at a given time. The stores do not
+// validate the policies, which is done before they reach the store.
+type Store interface {
+	// List returns the current version of every policy, in the order of their names.
+	List() ([]*Policy, error)
+	// Get returns the current version of the policy with the given name, or ErrNotFound.
+	Get(name string) (*Policy, error)
+	// GetVersion returns the given version of the policy with the given name, even if the policy was
+	// deleted, or ErrNotFound.
+	GetVersion(name string, version int) (*Policy, error)
+	// History returns every version of the policy with the given name, from the oldest to the current one,
+	// even if the policy was deleted, or ErrNotFound if the policy never existed.
+	History(name string) ([]*Policy, error)
+	// Create adds a new policy, or returns ErrExists. If a policy with the same name was deleted, the new
+	// policy continues its history, so its version is above the versions of the deleted policy.
+	Create(policy *Policy) (*Policy, error)
+	// Update adds a new version of the policy with the same name, with its rules, author and time of update,
+	// or returns ErrNotFound. It returns the new version.
+	Update(policy *Policy) (*Policy, error)
+	// Delete removes the policy with the given name, keeping its history, or returns ErrNotFound.
+	Delete(name string) error
+}
+
+// Seed creates in the store the given policies (e.g. the policies loaded from a directory at startup) that
+// never existed in it. The policies already in the store are kept as they are, since they may have been
+// updated or deleted through the API. A policy is created after the policy it extends, to be pinned to its
+// current version. The time of update of the policies is the time of the seeding.
+func Seed(store Store, policies []*Policy) error {
+	now := time.Now().UTC()
+	by_name := make(map[string]*Policy, len(policies))
+	for _, policy := range policies {
+		by_name[policy.Name] = policy
+	}
+
+	seeded := map[string]bool{}
+	var seed func(policy *Policy) error
+	seed = func(policy *Policy) error {
+		if seeded[policy.Name] {
+			return nil
+		}
+		seeded[policy.Name] = true
+		if base, ok := by_name[policy.Extends]; ok {
+			if err := seed(base); err != nil {
+				return err
+			}
+		}
+
+		_, err := store.History(policy.Name)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+
+		created := clonePolicy(policy)
+		if created.UpdatedAt.IsZero() {
+			created.UpdatedAt = now
+		}
+		// a base deleted through the API leaves the policy unpinned, without effective rules
+		if err := pin(store, created); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if _, err := store.Create(created); err != nil && !errors.Is(err, ErrExists) {
+			return err
+		}
+		return nil
+	}
+
+	for _, policy := range policies {
+		if err := seed(policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returns a copy of the policy, so that the policies kept by a store are not changed by its callers
+func clonePolicy(policy *Policy) *Policy {
+	clone := *policy
+	clone.Rules = append([]utils.Rule(nil), policy.Rules...)
+	return &clone
+}
+
+// returns the version of a new policy that continues the given history, which may be empty
+func nextVersion(policy *Policy, history []*Policy) int {
+	if len(history) > 0 && history[len(history)-1].Version >= policy.Version {
+		return history[len(history)-1].Version + 1
+	}
+	return policy.Version
+}
+
+// MemoryStore is a Store that keeps the policies in memory, so they are lost when the server stops. It is safe
+// for concurrent use.
+type MemoryStore struct {
+	mu sync.RWMutex
+	// every version of each policy, from the oldest to the current one, indexed by the name of the policy
+	versions map[string][]*Policy
+	// names of the deleted policies, whose versions are kept
+	deleted map[string]bool
+}
+
+// NewMemoryStore creates a store with the given policies. A policy replaces a previous policy with the
+// same name.
+func NewMemoryStore(policies ...*Policy) *MemoryStore {
+	store := &MemoryStore{
+		versions: make(map[string][]*Policy, len(policies)),
+		deleted:  map[string]bool{},
+	}
+	for _, policy := range policies {
+		store.versions[policy.Name] = []*Policy{clonePolicy(policy)}
+	}
+	return store
+}
+
+// returns the current version of a policy, or nil if the policy does not exist or was deleted
+func (s *MemoryStore) current(name string) *Policy {
+	versions := s.versions[name]
+	if len(versions) == 0 || s.deleted[name] {
+		return nil
+	}
+	return versions[len(versions)-1]
+}
+
+// List returns the current version of every policy, in the order of their names.
+func (s *MemoryStore) List() ([]*Policy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	policies := make([]*Policy, 0, len(s.versions))
+	for name := range s.versions {
+		if policy := s.current(name); policy != nil {
+			policies = append(policies, clonePolicy(policy))
+		}
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+	return policies, nil
+}
+
+// Get returns the current version of a policy.
+func (s *MemoryStore) Get(name string) (*Policy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	policy := s.current(name)
+	if policy == nil {
+		return nil, ErrNotFound
+	}
+	return clonePolicy(policy), nil
+}
+
+// GetVersion returns a version of a policy.
+func (s *MemoryStore) GetVersion(name string, version int) (*Policy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, policy := range s.versions[name] {
+		if policy.Version == version {
+			return clonePolicy(policy), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// History returns every version of a policy.
+func (s *MemoryStore) History(name string) ([]*Policy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	versions := s.versions[name]
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+	history := make([]*Policy, 0, len(versions))
+	for _, policy := range versions {
+		history = append(history, clonePolicy(policy))
+	}
+	return history, nil
+}
+
+// Create adds a new policy.
+func (s *MemoryStore) Create(policy *Policy) (*Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current(policy.Name) != nil {
+		return nil, ErrExists
+	}
+
+	created := clonePolicy(policy)
+	created.Version = nextVersion(policy, s.versions[policy.Name])
+	s.versions[policy.Name] = append(s.versions[policy.Name], created)
+	delete(s.deleted, policy.Name)
+	return clonePolicy(created), nil
+}
+
+// Update adds a new version of a policy.
+func (s *MemoryStore) Update(policy *Policy) (*Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := s.current(policy.Name)
+	if current == nil {
+		return nil, ErrNotFound
+	}
+
+	updated := clonePolicy(policy)
+	updated.Version = current.Version + 1
+	s.versions[policy.Name] = append(s.versions[policy.Name], updated)
+	return clonePolicy(updated), nil
+}
+
+// Delete removes a policy, keeping its history.
+func (s *MemoryStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current(name) == nil {
+		return ErrNotFound
+	}
+	s.deleted[name] = true
+	return nil
+}
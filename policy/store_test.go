@@ -0,0 +1,221 @@
+// unit tests to the stores of policies
+
+package policy
+
+import (
+	"database/sql"
+	"graphpass/utils"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+// tests the behavior shared by every store, which must start empty
+func testStore(t *testing.T, store Store) {
+	created_at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	basic := &Policy{Name: "basic", Version: 1, Rules: []utils.Rule{{Rule: "minSize", Value: 8}}, UpdatedAt: created_at}
+	corporate := &Policy{Name: "corporate", Version: 3, Rules: []utils.Rule{{Rule: "minSpecialChars", Value: 1, Param: "owasp"}},
+		Author: "security", UpdatedAt: created_at}
+
+	policies, err := store.List()
+	require.Nil(t, err)
+	assert.Empty(t, policies)
+
+	created, err := store.Create(corporate)
+	require.Nil(t, err)
+	assert.Equal(t, corporate, created)
+	_, err = store.Create(basic)
+	require.Nil(t, err)
+	_, err = store.Create(basic)
+	assert.ErrorIs(t, err, ErrExists)
+
+	policies, err = store.List()
+	require.Nil(t, err)
+	assert.Equal(t, []*Policy{basic, corporate}, policies, "the policies should be listed in the order of their names")
+
+	policy, err := store.Get("corporate")
+	require.Nil(t, err)
+	assert.Equal(t, corporate, policy)
+	_, err = store.Get("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	updated_at := created_at.Add(time.Hour)
+	updated, err := store.Update(&Policy{Name: "basic", Extends: "corporate", BaseVersion: 3, Rules: []utils.Rule{{Rule: "minSize", Value: 10}},
+		Author: "alice", UpdatedAt: updated_at})
+	require.Nil(t, err)
+	want := &Policy{Name: "basic", Version: 2, Extends: "corporate", BaseVersion: 3, Rules: []utils.Rule{{Rule: "minSize", Value: 10}},
+		Author: "alice", UpdatedAt: updated_at}
+	assert.Equal(t, want, updated, "the version should be incremented by the update")
+	policy, err = store.Get("basic")
+	require.Nil(t, err)
+	assert.Equal(t, want, policy)
+	_, err = store.Update(&Policy{Name: "missing", Rules: basic.Rules})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// the previous versions are kept
+	policy, err = store.GetVersion("basic", 1)
+	require.Nil(t, err)
+	assert.Equal(t, basic, policy)
+	_, err = store.GetVersion("basic", 3)
+	assert.ErrorIs(t, err, ErrNotFound)
+	history, err := store.History("basic")
+	require.Nil(t, err)
+	assert.Equal(t, []*Policy{basic, want}, history, "the versions should be listed from the oldest")
+	_, err = store.History("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// the history is kept after the policy is deleted, and continued when it is created again
+	require.Nil(t, store.Delete("basic"))
+	assert.ErrorIs(t, store.Delete("basic"), ErrNotFound)
+	_, err = store.Get("basic")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Update(&Policy{Name: "basic", Rules: basic.Rules})
+	assert.ErrorIs(t, err, ErrNotFound)
+	history, err = store.History("basic")
+	require.Nil(t, err)
+	assert.Len(t, history, 2)
+
+	created, err = store.Create(basic)
+	require.Nil(t, err)
+	assert.Equal(t, 3, created.Version, "the version should follow the versions of the deleted policy")
+	history, err = store.History("basic")
+	require.Nil(t, err)
+	assert.Len(t, history, 3)
+	require.Nil(t, store.Delete("basic"))
+}
+
+// Tests the memory store
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+
+	// the policies kept by the store cannot be changed by its callers
+	basic := &Policy{Name: "basic", Version: 1, Rules: []utils.Rule{{Rule: "minSize", Value: 8}}}
+	store := NewMemoryStore(basic)
+	basic.Rules[0].Value = 4
+	policy, _ := store.Get("basic")
+	assert.Equal(t, 8, policy.Rules[0].Value)
+}
+
+// Tests the file store
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	store, err := OpenFileStore(path)
+	require.Nil(t, err)
+
+	testStore(t, store)
+
+	// the policies are read again from the file
+	reopened, err := OpenFileStore(path)
+	require.Nil(t, err)
+	policies, err := reopened.List()
+	require.Nil(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, "corporate", policies[0].Name)
+	assert.Equal(t, "security", policies[0].Author)
+	history, err := reopened.History("basic")
+	require.Nil(t, err)
+	assert.Len(t, history, 3, "the history of the deleted policy should be read again from the file")
+
+	// the files written before the history was kept have the list of the current policies
+	require.Nil(t, os.WriteFile(path, []byte(`[{"name": "legacy", "version": 4, "rules": [{"rule": "minSize", "value": 8}]}]`), 0o600))
+	reopened, err = OpenFileStore(path)
+	require.Nil(t, err)
+	history, err = reopened.History("legacy")
+	require.Nil(t, err)
+	assert.Equal(t, []*Policy{{Name: "legacy", Version: 4, Rules: []utils.Rule{{Rule: "minSize", Value: 8}}}}, history)
+
+	require.Nil(t, os.WriteFile(path, []byte("not json"), 0o600))
+	_, err = OpenFileStore(path)
+	assert.NotNil(t, err)
+}
+
+// Tests the SQL store, on an in-memory SQLite database
+func TestSQLStore(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.Nil(t, err)
+	defer db.Close()
+	// each connection to ":memory:" is a different database
+	db.SetMaxOpenConns(1)
+
+	store, err := NewSQLStore(db)
+	require.Nil(t, err)
+
+	testStore(t, store)
+
+	// the table is kept when the store is created again
+	store, err = NewSQLStore(db)
+	require.Nil(t, err)
+	_, err = store.Get("corporate")
+	assert.Nil(t, err)
+
+	// the policies created before the history was kept get their current version as history
+	_, err = db.Exec("INSERT INTO policies (name, version, rules) VALUES ('legacy', 4, '[]')")
+	require.Nil(t, err)
+	store, err = NewSQLStore(db)
+	require.Nil(t, err)
+	history, err := store.History("legacy")
+	require.Nil(t, err)
+	assert.Equal(t, []*Policy{{Name: "legacy", Version: 4, Rules: []utils.Rule{}}}, history)
+
+	// the tables created before the inheritance of policies get its columns
+	legacy_db, err := sql.Open("sqlite", ":memory:")
+	require.Nil(t, err)
+	defer legacy_db.Close()
+	legacy_db.SetMaxOpenConns(1)
+	_, err = legacy_db.Exec(`CREATE TABLE policy_versions (name VARCHAR(255) NOT NULL, version INTEGER NOT NULL,
+		rules TEXT NOT NULL, author VARCHAR(255) NOT NULL, updated_at VARCHAR(64) NOT NULL, PRIMARY KEY (name, version))`)
+	require.Nil(t, err)
+	_, err = legacy_db.Exec("INSERT INTO policy_versions (name, version, rules, author, updated_at) VALUES ('legacy', 2, '[]', 'alice', '')")
+	require.Nil(t, err)
+	legacy_store, err := NewSQLStore(legacy_db)
+	require.Nil(t, err)
+	history, err = legacy_store.History("legacy")
+	require.Nil(t, err)
+	assert.Equal(t, []*Policy{{Name: "legacy", Version: 2, Rules: []utils.Rule{}, Author: "alice"}}, history)
+
+	// a policy created by another server after the check of the name violates the primary key, as simulated
+	// by a trigger
+	_, err = db.Exec(`CREATE TRIGGER concurrent_create AFTER INSERT ON policy_versions WHEN NEW.name = 'raced'
+		BEGIN INSERT INTO policies (name, version, rules) VALUES ('raced', 1, '[]'); END`)
+	require.Nil(t, err)
+	_, err = store.Create(&Policy{Name: "raced", Version: 1, Rules: []utils.Rule{{Rule: "minSize", Value: 8}}})
+	assert.ErrorIs(t, err, ErrExists)
+}
+
+// Tests the seeding of a store with the policies loaded at startup
+func TestSeed(t *testing.T) {
+	updated := &Policy{Name: "basic", Version: 2, Rules: []utils.Rule{{Rule: "minSize", Value: 10}}}
+	store := NewMemoryStore(updated, &Policy{Name: "deleted", Version: 1})
+	require.Nil(t, store.Delete("deleted"))
+
+	err := Seed(store, []*Policy{
+		{Name: "basic", Version: 1, Rules: []utils.Rule{{Rule: "minSize", Value: 8}}},
+		{Name: "corporate", Version: 1, Rules: []utils.Rule{{Rule: "minSize", Value: 12}}},
+		{Name: "deleted", Version: 1, Rules: []utils.Rule{{Rule: "minSize", Value: 12}}},
+	})
+
+	require.Nil(t, err)
+	policies, _ := store.List()
+	require.Len(t, policies, 2, "a policy deleted through the API should not be created again")
+	assert.Equal(t, updated, policies[0], "a policy already in the store should not be replaced")
+	assert.False(t, policies[1].UpdatedAt.IsZero(), "the time of the seeding should be the time of update")
+}
+
+// Tests that the seeding pins a policy to the version of the policy it extends, created before it
+func TestSeedWithInheritance(t *testing.T) {
+	store := NewMemoryStore()
+	err := Seed(store, []*Policy{
+		{Name: "a-finance", Version: 1, Extends: "corporate", Rules: []utils.Rule{{Rule: "minSize", Value: 14}}},
+		{Name: "corporate", Version: 5, Rules: []utils.Rule{{Rule: "minSize", Value: 12}}},
+	})
+
+	require.Nil(t, err)
+	finance, err := store.Get("a-finance")
+	require.Nil(t, err)
+	assert.Equal(t, 5, finance.BaseVersion)
+}
@@ -1 +1 @@
-go test graphpass graphpass/password graphpass/utils -cover
\ No newline at end of file
+go test graphpass graphpass/password graphpass/policy graphpass/utils -cover
\ No newline at end of file
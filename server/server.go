@@ -1,14 +1,18 @@
 package main
 
 import (
+	"database/sql"
 	"graphpass/graph"
 	"graphpass/graph/resolver"
+	"graphpass/password"
+	"graphpass/policy"
 	"log"
 	"net/http"
 	"os"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
+	_ "modernc.org/sqlite"
 )
 
 const defaultPort = "8080"
@@ -20,7 +24,59 @@ func main() {
 		port = defaultPort
 	}
 
-	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{}}))
+	// the notCommon rule is backed by a list of common passwords, loaded from the file set in BLOCKLIST_FILE
+	if path := os.Getenv("BLOCKLIST_FILE"); path != "" {
+		blocklist, err := password.LoadBlocklist(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		password.Register("notCommon", password.NotCommon(blocklist))
+		log.Printf("loaded %d common passwords from %s", blocklist.Len(), path)
+	}
+
+	// the notBreached rule is backed by a local mirror of the range API of breached passwords, either a
+	// directory (BREACHED_DIR) or a server implementing the API (BREACHED_URL)
+	if dir := os.Getenv("BREACHED_DIR"); dir != "" {
+		source, err := password.NewDirRangeSource(dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		password.Register("notBreached", password.NotBreached(source))
+	} else if url := os.Getenv("BREACHED_URL"); url != "" {
+		password.Register("notBreached", password.NotBreached(&password.HTTPRangeSource{BaseURL: url}))
+	}
+
+	// the policies are kept in the store chosen with POLICY_STORE_FILE or POLICY_STORE_SQLITE (see openPolicyStore)
+	policies, err := openPolicyStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the policies defined in the YAML files of the directory set in POLICIES_DIR are added to the store, unless
+	// the store already has them. They are loaded after the rules are registered, since the rules of each
+	// policy are checked.
+	if dir := os.Getenv("POLICIES_DIR"); dir != "" {
+		loaded, err := policy.LoadDir(dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := policy.Seed(policies, loaded); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("loaded %d policies from %s", len(loaded), dir)
+	}
+
+	// the policy mutations are only enabled with an admin token, set in POLICY_ADMIN_TOKEN, which the clients
+	// send as "Authorization: Bearer <token>"
+	admin_token := os.Getenv("POLICY_ADMIN_TOKEN")
+	if admin_token == "" {
+		log.Printf("the policy mutations are disabled, since POLICY_ADMIN_TOKEN is not set")
+	}
+
+	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &resolver.Resolver{
+		Store:      policies,
+		AdminToken: admin_token,
+	}}))
 
 	http.Handle("/", playground.Handler("GraphQL playground", "/query"))
 	http.Handle("/query", srv)
@@ -28,3 +84,19 @@ func main() {
 	log.Printf("connect to http://localhost:%s/ for GraphQL playground", port)
 	log.Fatal(http.ListenAndServe(":"+port, nil))
 }
+
+// opens the store of the policies managed through the API: a JSON file (POLICY_STORE_FILE), a SQLite
+// database (POLICY_STORE_SQLITE) or, by default, the memory, in which the policies are lost when the server stops
+func openPolicyStore() (policy.Store, error) {
+	if path := os.Getenv("POLICY_STORE_FILE"); path != "" {
+		return policy.OpenFileStore(path)
+	}
+	if path := os.Getenv("POLICY_STORE_SQLITE"); path != "" {
+		db, err := sql.Open("sqlite", path)
+		if err != nil {
+			return nil, err
+		}
+		return policy.NewSQLStore(db)
+	}
+	return policy.NewMemoryStore(), nil
+}
@@ -0,0 +1,262 @@
+package utils
+
+import (
+	"fmt"
+	"graphpass/password"
+	"strings"
+)
+
+// Issue is a problem found in a set of rules, about one of its rules.
+type Issue struct {
+	Rule    string
+	Message string
+}
+
+// Lint is the result of LintRules. The errors make the rules invalid, or impossible to satisfy by any password,
+// and the warnings are about rules that are valid but most likely not what was intended.
+type Lint struct {
+	Errors   []Issue
+	Warnings []Issue
+}
+
+// adds an error about a rule
+func (l *Lint) addError(rule string, format string, args ...any) {
+	l.Errors = append(l.Errors, Issue{Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// adds a warning about a rule
+func (l *Lint) addWarning(rule string, format string, args ...any) {
+	l.Warnings = append(l.Warnings, Issue{Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// the rules that set the minimum of a class of characters, in the order their minimums are added up, with the
+// name of the class in the position rules
+var classMinimumRules = []struct {
+	rule  string
+	class string
+}{
+	{rule: "minUppercase", class: "upper"},
+	{rule: "minLowercase", class: "lower"},
+	{rule: "minDigit", class: "digit"},
+	{rule: "minSpecialChars", class: "special"},
+}
+
+// the classes of characters counted by the minCharClasses rule
+var countedClasses = []string{"upper", "lower", "digit", "special"}
+
+// LintRules checks a set of rules without a password, so that a contradictory set of rules, which would reject
+// every password, is found before it is used. Each rule is checked as in CheckRules, and the valid rules are
+// then checked together:
+//   - a maximum rule below the minimum rule of the same quantity is an error, as in CheckRules;
+//   - the minimums of the classes of characters (minUppercase, minLowercase, minDigit and minSpecialChars) adding
+//     up to more than maxSize is an error, and to more than minSize a warning, since minSize has no effect;
+//   - a rule given more than once with the same parameter is a warning;
+//   - a class of characters required by a rule (e.g. minDigit or firstCharClass) but not accepted by the
+//     forbiddenChars and allowedCharset rules, or by the other position rules, is an error.
+//
+// At most MaxRules rules are checked.
+func LintRules(rules []Rule, unicode bool) Lint {
+	var lint Lint
+	if len(rules) == 0 {
+		lint.addWarning("", "there are no rules, so every password is accepted")
+		return lint
+	}
+	if len(rules) > MaxRules {
+		lint.addError("", "there are %d rules, above the maximum of %d", len(rules), MaxRules)
+		return lint
+	}
+
+	valid := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		if err := checkRule(rule); err != nil {
+			lint.addError(rule.Rule, "%s", err.Error())
+			continue
+		}
+		valid = append(valid, rule)
+	}
+	lint.Errors = append(lint.Errors, boundsIssues(valid)...)
+
+	lintDuplicates(valid, &lint)
+	lintLength(valid, &lint)
+	lintCharset(valid, password.Options{Unicode: unicode}, &lint)
+	return lint
+}
+
+// warns about the rules given more than once with the same parameter, all of which are applied
+func lintDuplicates(rules []Rule, lint *Lint) {
+	counts := map[Rule]int{}
+	for _, rule := range rules {
+		counts[Rule{Rule: rule.Rule, Param: rule.Param}]++
+	}
+	for _, rule := range rules {
+		key := Rule{Rule: rule.Rule, Param: rule.Param}
+		if counts[key] < 2 {
+			continue
+		}
+		lint.addWarning(rule.Rule, "the rule '%s' is given %d times. Every occurrence is applied, so only the strictest one matters",
+			rule.Rule, counts[key])
+		delete(counts, key) // reported once
+	}
+}
+
+// returns the strictest value of the given rule among the rules: the highest of a minimum rule or the lowest of
+// a maximum rule, and whether the rule was found
+func strictestValue(rules []Rule, name string) (int, bool) {
+	value, found := 0, false
+	for _, rule := range rules {
+		if rule.Rule != name {
+			continue
+		}
+		if !found || (strings.HasPrefix(name, "min") && rule.Value > value) || (strings.HasPrefix(name, "max") && rule.Value < value) {
+			value = rule.Value
+		}
+		found = true
+	}
+	return value, found
+}
+
+// checks the minimum length required by the rules against the minSize and maxSize rules. The minimums of the
+// special characters of different sets are not added up, since the sets may overlap.
+func lintLength(rules []Rule, lint *Lint) {
+	required := 0
+	var names []string
+	for _, class_rule := range classMinimumRules {
+		if value, _ := strictestValue(rules, class_rule.rule); value > 0 {
+			required += value
+			names = append(names, "'"+class_rule.rule+"'")
+		}
+	}
+
+	max_size, has_max := strictestValue(rules, "maxSize")
+	min_size, has_min := strictestValue(rules, "minSize")
+	switch {
+	case has_max && required > max_size:
+		lint.addError("maxSize", "the rules %s require at least %d characters together, above the value %d of the rule 'maxSize'",
+			strings.Join(names, ", "), required, max_size)
+	case has_min && required > min_size:
+		lint.addWarning("minSize", "the rules %s require at least %d characters together, above the value %d of the rule 'minSize', which has no effect",
+			strings.Join(names, ", "), required, min_size)
+	}
+
+	if !has_max {
+		return
+	}
+	for _, name := range []string{"minUniqueChars", "minCharClasses"} {
+		if value, _ := strictestValue(rules, name); value > max_size {
+			lint.addError(name, "the rule '%s' requires at least %d characters, above the value %d of the rule 'maxSize'",
+				name, value, max_size)
+		}
+	}
+}
+
+// checks of the characters accepted by a set of rules, which only go through the boundaries of the sets of
+// characters of the rules (see password.CharBoundaries) and remember the classes of characters already checked,
+// indexed by the name of the class and its parameter
+type charsetLint struct {
+	boundaries []rune
+	accepted   func(rune) bool
+	opts       password.Options
+	classes    map[Rule]bool
+}
+
+// tells if some character satisfies every predicate, checking only the boundaries
+func (c *charsetLint) anyChar(predicates ...func(rune) bool) bool {
+	for _, char := range c.boundaries {
+		if char >= 0xD800 && char <= 0xDFFF {
+			continue // surrogates are not valid characters
+		}
+		matches := true
+		for _, predicate := range predicates {
+			if !predicate(char) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// tells if some accepted character belongs to the class of characters, evaluating each class once
+func (c *charsetLint) acceptsClass(class string, param string) bool {
+	key := Rule{Rule: class, Param: param}
+	if accepts, found := c.classes[key]; found {
+		return accepts
+	}
+	accepts := c.anyChar(c.accepted, password.CharClass(class, param, c.opts))
+	c.classes[key] = accepts
+	return accepts
+}
+
+// checks that the classes of characters required by the rules are accepted by the forbiddenChars and
+// allowedCharset rules, and that the position rules accept some character at each position
+func lintCharset(rules []Rule, opts password.Options, lint *Lint) {
+	charset := &charsetLint{
+		boundaries: password.CharBoundaries(rules),
+		accepted:   password.AcceptedChars(rules),
+		opts:       opts,
+		classes:    map[Rule]bool{},
+	}
+	if charset.accepted != nil {
+		if !charset.anyChar(charset.accepted) {
+			lint.addError("allowedCharset", "no character is accepted by the rules forbiddenChars and allowedCharset together")
+			return
+		}
+
+		for _, rule := range rules {
+			for _, class_rule := range classMinimumRules {
+				if rule.Rule == class_rule.rule && rule.Value > 0 && !charset.acceptsClass(class_rule.class, rule.Param) {
+					lint.addError(rule.Rule, "the rule '%s' can never be satisfied, since none of its characters is accepted by the rules forbiddenChars and allowedCharset",
+						rule.Rule)
+				}
+			}
+		}
+
+		if value, _ := strictestValue(rules, "minCharClasses"); value > 0 {
+			classes := 0
+			for _, class := range countedClasses {
+				if charset.acceptsClass(class, "") {
+					classes++
+				}
+			}
+			if classes < value {
+				lint.addError("minCharClasses", "the rule 'minCharClasses' requires %d classes of characters, but the rules forbiddenChars and allowedCharset accept only %d",
+					value, classes)
+			}
+		}
+	}
+
+	lintPosition(rules, charset, "first", "firstCharClass", "firstCharNotClass", lint)
+	lintPosition(rules, charset, "last", "lastCharClass", "lastCharNotClass", lint)
+}
+
+// checks that some accepted character can be at a position of the password with the rules about its class
+func lintPosition(rules []Rule, charset *charsetLint, position string, class_name string, not_class_name string, lint *Lint) {
+	var predicates []func(rune) bool
+	if charset.accepted != nil {
+		predicates = append(predicates, charset.accepted)
+	}
+
+	culprit := ""
+	for _, rule := range rules {
+		switch rule.Rule {
+		case class_name:
+			predicates = append(predicates, password.CharClass(rule.Param, "", charset.opts))
+		case not_class_name:
+			in_class := password.CharClass(rule.Param, "", charset.opts)
+			predicates = append(predicates, func(char rune) bool { return !in_class(char) })
+		default:
+			continue
+		}
+		if culprit == "" {
+			culprit = rule.Rule
+		}
+	}
+
+	if culprit != "" && !charset.anyChar(predicates...) {
+		lint.addError(culprit, "no character can be the %s character of the password with the rules %s, %s, forbiddenChars and allowedCharset",
+			position, class_name, not_class_name)
+	}
+}
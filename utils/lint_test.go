@@ -0,0 +1,146 @@
+// unit tests to the linting of a set of rules without a password
+package utils
+
+import (
+	"graphpass/password"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// CASE 01: consistent rules
+func TestLintRulesWithConsistentRules(t *testing.T) {
+	lint := LintRules([]Rule{
+		{Rule: "minSize", Value: 12},
+		{Rule: "maxSize", Value: 64},
+		{Rule: "minUppercase", Value: 1},
+		{Rule: "minDigit", Value: 2},
+		{Rule: "allowedCharset", Param: "A-Za-z0-9!@#"},
+		{Rule: "firstCharClass", Param: "letter"},
+	}, false)
+
+	assert.Empty(t, lint.Errors)
+	assert.Empty(t, lint.Warnings)
+}
+
+// CASE 02: rules that no password can satisfy, or that are most likely not intended
+func TestLintRulesWithContradictoryRules(t *testing.T) {
+	tests := []struct {
+		name        string
+		rules       []Rule
+		want_output Lint
+	}{
+		{
+			name:  "no rules",
+			rules: []Rule{},
+			want_output: Lint{Warnings: []Issue{
+				{Message: "there are no rules, so every password is accepted"},
+			}},
+		},
+		{
+			name:  "invalid rules are reported together",
+			rules: []Rule{{Rule: "minSize", Value: -1}, {Rule: "matchesRegex", Param: "("}, {Rule: "minDigit", Value: 1}},
+			want_output: Lint{Errors: []Issue{
+				{Rule: "minSize", Message: "the value -1 of the rule 'minSize' is invalid. Negative values are not accepted"},
+				{Rule: "matchesRegex", Message: "the parameter '(' of the rule 'matchesRegex' is invalid: error parsing regexp: missing closing ): `(`"},
+			}},
+		},
+		{
+			name:  "maximum below the minimum",
+			rules: []Rule{{Rule: "minDigit", Value: 3}, {Rule: "maxDigit", Value: 2}},
+			want_output: Lint{Errors: []Issue{
+				{Rule: "maxDigit", Message: "the value 2 of the rule 'maxDigit' is invalid. It is below the value 3 of the rule 'minDigit'"},
+			}},
+		},
+		{
+			name:  "minimums of the classes above minSize",
+			rules: []Rule{{Rule: "minSize", Value: 8}, {Rule: "minUppercase", Value: 4}, {Rule: "minDigit", Value: 4}, {Rule: "minSpecialChars", Value: 4}},
+			want_output: Lint{Warnings: []Issue{
+				{Rule: "minSize", Message: "the rules 'minUppercase', 'minDigit', 'minSpecialChars' require at least 12 characters together, above the value 8 of the rule 'minSize', which has no effect"},
+			}},
+		},
+		{
+			name:  "minimums of the classes above maxSize",
+			rules: []Rule{{Rule: "maxSize", Value: 10}, {Rule: "minUppercase", Value: 6}, {Rule: "minLowercase", Value: 6}},
+			want_output: Lint{Errors: []Issue{
+				{Rule: "maxSize", Message: "the rules 'minUppercase', 'minLowercase' require at least 12 characters together, above the value 10 of the rule 'maxSize'"},
+			}},
+		},
+		{
+			name:  "duplicate rules",
+			rules: []Rule{{Rule: "minSize", Value: 8}, {Rule: "minSize", Value: 10}, {Rule: "minSpecialChars", Value: 1, Param: "owasp"}, {Rule: "minSpecialChars", Value: 1}},
+			want_output: Lint{Warnings: []Issue{
+				{Rule: "minSize", Message: "the rule 'minSize' is given 2 times. Every occurrence is applied, so only the strictest one matters"},
+			}},
+		},
+		{
+			name:  "class excluded by the allowed charset",
+			rules: []Rule{{Rule: "allowedCharset", Param: "a-z0-9"}, {Rule: "minUppercase", Value: 1}, {Rule: "minCharClasses", Value: 3}},
+			want_output: Lint{Errors: []Issue{
+				{Rule: "minUppercase", Message: "the rule 'minUppercase' can never be satisfied, since none of its characters is accepted by the rules forbiddenChars and allowedCharset"},
+				{Rule: "minCharClasses", Message: "the rule 'minCharClasses' requires 3 classes of characters, but the rules forbiddenChars and allowedCharset accept only 2"},
+			}},
+		},
+		{
+			name:  "no accepted character",
+			rules: []Rule{{Rule: "allowedCharset", Param: "0-9"}, {Rule: "forbiddenChars", Param: "0-9"}},
+			want_output: Lint{Errors: []Issue{
+				{Rule: "allowedCharset", Message: "no character is accepted by the rules forbiddenChars and allowedCharset together"},
+			}},
+		},
+		{
+			name:  "position rules in conflict",
+			rules: []Rule{{Rule: "allowedCharset", Param: "a-z0-9"}, {Rule: "firstCharClass", Param: "upper"}, {Rule: "lastCharClass", Param: "digit"}, {Rule: "lastCharNotClass", Param: "digit"}},
+			want_output: Lint{Errors: []Issue{
+				{Rule: "firstCharClass", Message: "no character can be the first character of the password with the rules firstCharClass, firstCharNotClass, forbiddenChars and allowedCharset"},
+				{Rule: "lastCharClass", Message: "no character can be the last character of the password with the rules lastCharClass, lastCharNotClass, forbiddenChars and allowedCharset"},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.want_output, LintRules(test.rules, false), test.name)
+	}
+}
+
+// CASE 03: classes of characters of the Unicode mode
+func TestLintRulesWithUnicodeMode(t *testing.T) {
+	rules := []Rule{{Rule: "allowedCharset", Param: `\p{Greek}`}, {Rule: "minUppercase", Value: 1}}
+
+	assert.NotEmpty(t, LintRules(rules, false).Errors, "no Greek letter is uppercase in ASCII mode")
+	assert.Empty(t, LintRules(rules, true).Errors)
+}
+
+// CASE 04: sets of rules that are costly to check
+func TestLintRulesWithCostlyRules(t *testing.T) {
+	rules := []Rule{{Rule: "allowedCharset", Param: "a-z"}}
+	for i := 0; i < 20; i++ {
+		rules = append(rules, Rule{Rule: "minDigit", Value: 1})
+	}
+	lint := LintRules(rules, true)
+	assert.Len(t, lint.Errors, 20)
+
+	// each class of characters is checked once, going only through the boundaries of the sets of characters
+	checked := 0
+	charset := &charsetLint{
+		boundaries: password.CharBoundaries(rules),
+		accepted:   func(char rune) bool { checked++; return char >= 'a' && char <= 'z' },
+		opts:       password.Options{Unicode: true},
+		classes:    map[Rule]bool{},
+	}
+	for _, rule := range rules[1:] {
+		assert.False(t, charset.acceptsClass("digit", rule.Param))
+	}
+	assert.LessOrEqual(t, checked, len(charset.boundaries))
+	assert.Len(t, charset.classes, 1)
+
+	lint = LintRules([]Rule{{Rule: "forbiddenChars", Param: strings.Repeat("ab", MaxParamLength)}}, false)
+	assert.Equal(t, []Issue{{Rule: "forbiddenChars", Message: "the parameter of the rule 'forbiddenChars' has 2048 bytes, above the maximum of 1024"}}, lint.Errors)
+
+	for len(rules) <= MaxRules {
+		rules = append(rules, Rule{Rule: "minDigit", Value: 1})
+	}
+	lint = LintRules(rules, false)
+	assert.Equal(t, []Issue{{Message: "there are 101 rules, above the maximum of 100"}}, lint.Errors)
+}
@@ -1,58 +1,175 @@
 package utils
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"graphpass/graph/model"
+	"graphpass/password"
+)
 
-type Rule struct {
-	Rule  string
-	Value int
-}
+// Rule is a rule chosen by the user, in the format expected by the password validator
+type Rule = password.RuleConfig
 
-var acceptedRules = []string{
-	"minSize",
-	"minUppercase",
-	"minLowercase",
-	"minDigit",
-	"minSpecialChars",
-	"noRepeted",
+// the rules that set a maximum, indexed to the rules that set the minimum of the same quantity
+var boundedRules = map[string]string{
+	"maxSize":         "minSize",
+	"maxUppercase":    "minUppercase",
+	"maxLowercase":    "minLowercase",
+	"maxDigit":        "minDigit",
+	"maxSpecialChars": "minSpecialChars",
 }
 
-// generic helper function that checks if an element exists within a slice of strings
-func contains(slice_string []string, elem string) bool {
-	for _, item := range slice_string {
-		if item == elem {
-			return true
+// MaxRules is the maximum number of rules of a request or of the effective rules of a policy, which bounds the
+// cost of checking and applying them.
+const MaxRules = 100
+
+// calls the function for each pair of a maximum rule and a minimum rule of the same quantity in which the
+// maximum is below the minimum, since no password could satisfy both rules, until the function returns false.
+// The rules about special characters are only compared when they use the same set of special characters.
+func visitBoundsConflicts(rules []Rule, visit func(max_rule Rule, min_rule Rule) bool) {
+	for _, max_rule := range rules {
+		min_name, ok := boundedRules[max_rule.Rule]
+		if !ok {
+			continue
+		}
+		for _, min_rule := range rules {
+			if min_rule.Rule == min_name && min_rule.Param == max_rule.Param && max_rule.Value < min_rule.Value {
+				if !visit(max_rule, min_rule) {
+					return
+				}
+			}
 		}
 	}
-	return false
 }
 
-// MapToStruct is a helper function that converts the rules received from the user from the format of
-// []map[string]interface{} to a struct. The use of this function is to enforce the typing of the received
-// rules, which are originally in the format of JSON array with pairs of <key>:<value>. The gqlgen library
-// converts the Map scalar type into []map[string]interface{}, which has generic typing for the <value>.
-// This function receives this format, converts it into a struct and verifies the validity of the received rules.
-// The rules are considered valid if they are within the accepted rules and if the configuration value of the rule
-// is positive. This function is also one of the first points of data validation in the API which ensures that the
-// next functions that retrieve the data do so in a correct and valid format
-func MapToStruct(rules_map []map[string]interface{}) ([]Rule, error) {
-	rules_struct := []Rule{}
+// returns the message about a maximum rule below a minimum rule of the same quantity
+func boundsMessage(max_rule Rule, min_rule Rule) string {
+	return fmt.Sprintf("the value %d of the rule '%s' is invalid. It is below the value %d of the rule '%s'",
+		max_rule.Value, max_rule.Rule, min_rule.Value, min_rule.Rule)
+}
 
-	for _, rule_item := range rules_map {
-		rule := rule_item["rule"].(string)
-		value := int(rule_item["value"].(int64)) // by default gqlgen converts the received data to int64
+// checkBounds verifies that the maximum set by a rule is never below the minimum of the same quantity set by
+// another rule of the same request, returning an error about the first pair of rules in conflict.
+func checkBounds(rules []Rule) error {
+	var err error
+	visitBoundsConflicts(rules, func(max_rule Rule, min_rule Rule) bool {
+		err = errors.New(boundsMessage(max_rule, min_rule))
+		return false
+	})
+	return err
+}
 
-		if value < 0 {
-			return nil, fmt.Errorf("the value %d of the rule '%s' is invalid. Negative values are not accepted", value, rule)
-		}
+// boundsIssues is like checkBounds, but returns an issue about the maximum rule for each pair of rules in
+// conflict, to report them all in LintRules.
+func boundsIssues(rules []Rule) []Issue {
+	var issues []Issue
+	visitBoundsConflicts(rules, func(max_rule Rule, min_rule Rule) bool {
+		issues = append(issues, Issue{Rule: max_rule.Rule, Message: boundsMessage(max_rule, min_rule)})
+		return true
+	})
+	return issues
+}
+
+// MaxParamLength is the maximum length of the parameter of a rule, in bytes, which bounds the cost of parsing
+// and applying it.
+const MaxParamLength = 1024
+
+// checkRule verifies a single rule: it must be registered in the rule registry of the password package and be
+// a value of the RuleName enum of the schema, its configuration value must be positive and its parameter, when
+// given, must be at most MaxParamLength bytes long and accepted by the rule.
+func checkRule(rule Rule) error {
+	if rule.Value < 0 {
+		return fmt.Errorf("the value %d of the rule '%s' is invalid. Negative values are not accepted", rule.Value, rule.Rule)
+	}
+	if len(rule.Param) > MaxParamLength {
+		return fmt.Errorf("the parameter of the rule '%s' has %d bytes, above the maximum of %d", rule.Rule, len(rule.Param), MaxParamLength)
+	}
+
+	if _, ok := password.Lookup(rule.Rule); !ok {
+		return fmt.Errorf("the rule '%s' is invalid. List of accepted rules: %v", rule.Rule, password.RuleNames())
+	}
+
+	// the rules sent in a request are already restricted to the RuleName enum by the schema, but not the
+	// rules of a policy, which would then be returned by the API with a name outside of the enum
+	if !model.RuleName(rule.Rule).IsValid() {
+		return fmt.Errorf("the rule '%s' is not available in the API. Its name must be added to the RuleName enum of the GraphQL schema", rule.Rule)
+	}
+
+	// checks the configuration that is specific of the rule, such as its parameter
+	return password.CheckConfig(rule)
+}
 
-		if !contains(acceptedRules, rule) {
-			return nil, fmt.Errorf("the rule '%s' is invalid. List of accepted rules: %v", rule, acceptedRules)
+// CheckRules verifies the rules chosen by the user, whether they were received in a request or defined in a
+// policy on the server. The rules are considered valid if they are registered in the rule registry of the password
+// package, if the configuration value of the rule is positive and if the parameter, when given, is accepted by the
+// rule. In addition, no maximum rule can be below the minimum rule of the same quantity (e.g. maxSize 6 and minSize 8),
+// and there can be at most MaxRules rules.
+func CheckRules(rules []Rule) error {
+	if len(rules) > MaxRules {
+		return fmt.Errorf("there are %d rules, above the maximum of %d", len(rules), MaxRules)
+	}
+	for _, rule := range rules {
+		if err := checkRule(rule); err != nil {
+			return err
 		}
+	}
 
-		rules_struct = append(rules_struct, Rule{
-			Rule:  rule,
-			Value: value,
-		})
+	return checkBounds(rules)
+}
+
+// MapToStruct is a helper function that converts the rules received from the user from the RuleInput type
+// generated by gqlgen to the Rule struct used by the password validator. The shape of each rule (a rule name
+// from the RuleName enum and an integer value) is already enforced by the GraphQL schema, so a malformed rule
+// is rejected with a GraphQL error before reaching the resolver. This function verifies what the schema cannot
+// express through CheckRules. This function is also one of the first points of data validation in the API which
+// ensures that the next functions that retrieve the data do so in a correct and valid format
+func MapToStruct(rules_input []*model.RuleInput) ([]Rule, error) {
+	rules_struct := MapToRules(rules_input)
+	if err := CheckRules(rules_struct); err != nil {
+		return nil, err
 	}
 	return rules_struct, nil
 }
+
+// MapToRules converts the rules received from the user from the RuleInput type generated by gqlgen to the Rule
+// struct used by the password validator, without checking them. It is used by MapToStruct, which checks them,
+// and to lint the rules with LintRules.
+func MapToRules(rules_input []*model.RuleInput) []Rule {
+	rules_struct := []Rule{}
+
+	for _, rule_item := range rules_input {
+		rule_struct := Rule{
+			Rule:  string(rule_item.Rule),
+			Value: rule_item.Value,
+		}
+		if rule_item.Param != nil {
+			rule_struct.Param = *rule_item.Param
+		}
+		rules_struct = append(rules_struct, rule_struct)
+	}
+	return rules_struct
+}
+
+// returns the value of an optional string of the schema, or an empty string if it was not given
+func optionalString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// MapToUserInfo converts the user context received from the user from the UserContextInput type generated
+// by gqlgen to the UserInfo struct used by the password validator. Both the context and each of its fields
+// are optional, and a missing value is left empty, so it is never looked up in the password.
+func MapToUserInfo(context_input *model.UserContextInput) password.UserInfo {
+	if context_input == nil {
+		return password.UserInfo{}
+	}
+	return password.UserInfo{
+		Username:    optionalString(context_input.Username),
+		Email:       optionalString(context_input.Email),
+		FirstName:   optionalString(context_input.FirstName),
+		LastName:    optionalString(context_input.LastName),
+		CompanyName: optionalString(context_input.CompanyName),
+	}
+}
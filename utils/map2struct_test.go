@@ -1,8 +1,10 @@
-// unit tests to process of transforming the type []map[string]interface{} to struct
+// unit tests to process of transforming the type []*model.RuleInput to struct
 package utils
 
 import (
 	"fmt"
+	"graphpass/graph/model"
+	"graphpass/password"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,11 +12,11 @@ import (
 
 // CASE 01: valid input
 func TestMapToStructWithValidInput(t *testing.T) {
-	rulesMap := []map[string]interface{}{
-		{"rule": "minSize", "value": int64(10)},
-		{"rule": "minUppercase", "value": int64(3)},
-		{"rule": "minLowercase", "value": int64(2)},
-		{"rule": "minDigit", "value": int64(1)},
+	rulesInput := []*model.RuleInput{
+		{Rule: model.RuleNameMinSize, Value: 10},
+		{Rule: model.RuleNameMinUppercase, Value: 3},
+		{Rule: model.RuleNameMinLowercase, Value: 2},
+		{Rule: model.RuleNameMinDigit, Value: 1},
 	}
 	expectedRulesStruct := []Rule{
 		{Rule: "minSize", Value: 10},
@@ -23,7 +25,7 @@ func TestMapToStructWithValidInput(t *testing.T) {
 		{Rule: "minDigit", Value: 1},
 	}
 
-	rulesStruct, err := MapToStruct(rulesMap)
+	rulesStruct, err := MapToStruct(rulesInput)
 
 	// in this case no error should be thrown
 	assert.Nil(t, err, "MapToStruct returned an unexpected error, even with valid input.")
@@ -34,27 +36,143 @@ func TestMapToStructWithValidInput(t *testing.T) {
 
 // CASE 02: invalid rule
 func TestMapToStructWithInvalidRule(t *testing.T) {
-	rulesMap := []map[string]interface{}{
-		{"rule": "invalidRule", "value": int64(10)},
+	rulesInput := []*model.RuleInput{
+		{Rule: model.RuleName("invalidRule"), Value: 10},
 	}
 
-	_, err := MapToStruct(rulesMap)
+	_, err := MapToStruct(rulesInput)
 
 	// a error must have been thrown
 	assert.NotNil(t, err, "MapToStruct did not return an error, even with an invalid rule.")
-	expectedErrorMsg := fmt.Sprintf("the rule '%s' is invalid. List of accepted rules: %v", rulesMap[0]["rule"], acceptedRules)
+	expectedErrorMsg := fmt.Sprintf("the rule '%s' is invalid. List of accepted rules: %v", rulesInput[0].Rule, password.RuleNames())
 	assert.Equal(t, expectedErrorMsg, err.Error())
 }
 
 // CASE 03: invalid rule value
 func TestMapToStructInvalidValue(t *testing.T) {
-	rulesMap := []map[string]interface{}{
-		{"rule": "minSize", "value": int64(-10)},
+	rulesInput := []*model.RuleInput{
+		{Rule: model.RuleNameMinSize, Value: -10},
 	}
 
-	_, err := MapToStruct(rulesMap)
+	_, err := MapToStruct(rulesInput)
 
 	assert.NotNil(t, err, "MapToStruct did not return an error, even with an negative rule value.")
-	expectedErrorValueMsg := fmt.Sprintf("the value %d of the rule '%s' is invalid. Negative values are not accepted", rulesMap[0]["value"], rulesMap[0]["rule"])
+	expectedErrorValueMsg := fmt.Sprintf("the value %d of the rule '%s' is invalid. Negative values are not accepted", rulesInput[0].Value, rulesInput[0].Rule)
 	assert.Equal(t, expectedErrorValueMsg, err.Error())
 }
+
+// CASE 04: valid parameter
+func TestMapToStructWithParam(t *testing.T) {
+	param := "_."
+	rulesInput := []*model.RuleInput{
+		{Rule: model.RuleNameMinSpecialChars, Value: 1, Param: &param},
+	}
+
+	rulesStruct, err := MapToStruct(rulesInput)
+
+	assert.Nil(t, err, "MapToStruct returned an unexpected error, even with valid parameter.")
+	assert.Equal(t, []Rule{{Rule: "minSpecialChars", Value: 1, Param: "_."}}, rulesStruct)
+}
+
+// CASE 05: parameter not accepted by the rule
+func TestMapToStructInvalidParam(t *testing.T) {
+	param := "owsap"
+	rulesInput := []*model.RuleInput{
+		{Rule: model.RuleNameMinSize, Value: 8, Param: &param},
+	}
+
+	_, err := MapToStruct(rulesInput)
+
+	assert.NotNil(t, err, "MapToStruct did not return an error, even with a parameter in a rule that does not accept it.")
+	assert.Equal(t, "the rule 'minSize' does not accept a parameter", err.Error())
+
+	rulesInput[0].Rule = model.RuleNameMinSpecialChars
+	_, err = MapToStruct(rulesInput)
+
+	assert.NotNil(t, err, "MapToStruct did not return an error, even with an invalid set of special characters.")
+}
+
+// CASE 06: maximum below the minimum of the same quantity
+func TestMapToStructMaxBelowMin(t *testing.T) {
+	rulesInput := []*model.RuleInput{
+		{Rule: model.RuleNameMaxSize, Value: 6},
+		{Rule: model.RuleNameMinDigit, Value: 2},
+		{Rule: model.RuleNameMinSize, Value: 8},
+	}
+
+	_, err := MapToStruct(rulesInput)
+
+	assert.NotNil(t, err, "MapToStruct did not return an error, even with a maximum below the minimum.")
+	assert.Equal(t, "the value 6 of the rule 'maxSize' is invalid. It is below the value 8 of the rule 'minSize'", err.Error())
+
+	// equal bounds and bounds of special characters of different sets are accepted
+	owasp := "owasp"
+	rulesInput = []*model.RuleInput{
+		{Rule: model.RuleNameMaxSize, Value: 8},
+		{Rule: model.RuleNameMinSize, Value: 8},
+		{Rule: model.RuleNameMinSpecialChars, Value: 3, Param: &owasp},
+		{Rule: model.RuleNameMaxSpecialChars, Value: 1},
+	}
+
+	_, err = MapToStruct(rulesInput)
+
+	assert.Nil(t, err, "MapToStruct returned an unexpected error, even with consistent bounds.")
+}
+
+// CASE 07: user context with missing fields
+func TestMapToUserInfo(t *testing.T) {
+	email := "john.doe@acme.com"
+	firstName := "John"
+
+	userInfo := MapToUserInfo(&model.UserContextInput{Email: &email, FirstName: &firstName})
+
+	assert.Equal(t, password.UserInfo{Email: "john.doe@acme.com", FirstName: "John"}, userInfo)
+	assert.Equal(t, password.UserInfo{}, MapToUserInfo(nil), "a missing context should be mapped to an empty UserInfo")
+}
+
+// CASE 08: invalid regular expression
+func TestMapToStructInvalidRegex(t *testing.T) {
+	pattern := "^[A-Za-z"
+	rulesInput := []*model.RuleInput{
+		{Rule: model.RuleNameMatchesRegex, Value: 0, Param: &pattern},
+	}
+
+	_, err := MapToStruct(rulesInput)
+
+	assert.NotNil(t, err, "MapToStruct did not return an error, even with an invalid regular expression.")
+
+	pattern = "^[A-Za-z]"
+	rulesStruct, err := MapToStruct(rulesInput)
+
+	assert.Nil(t, err, "MapToStruct returned an unexpected error, even with a valid regular expression.")
+	assert.Equal(t, []Rule{{Rule: "matchesRegex", Value: 0, Param: "^[A-Za-z]"}}, rulesStruct)
+}
+
+// CASE 09: registered rule that is not a value of the RuleName enum, e.g. in a policy
+func TestCheckRulesWithRuleOutsideOfTheSchema(t *testing.T) {
+	t.Cleanup(func() { password.Unregister("noCompanyName") })
+	password.Register("noCompanyName", password.RuleFunc(func(pass string, config password.RuleConfig, opts password.Options) password.Result {
+		return password.Result{Rule: config.Rule, Passed: true}
+	}))
+
+	err := CheckRules([]Rule{{Rule: "noCompanyName", Value: 0}})
+
+	assert.NotNil(t, err, "CheckRules did not return an error, even with a rule outside of the RuleName enum.")
+	assert.Equal(t, "the rule 'noCompanyName' is not available in the API. Its name must be added to the RuleName enum of the GraphQL schema", err.Error())
+}
+
+// CASE 10: more rules than accepted
+func TestCheckRulesWithTooManyRules(t *testing.T) {
+	rules := []Rule{}
+	for i := 0; i < MaxRules/2; i++ {
+		rules = append(rules, Rule{Rule: "maxSize", Value: 1}, Rule{Rule: "minSize", Value: 2})
+	}
+
+	err := CheckRules(rules)
+	assert.NotNil(t, err, "CheckRules did not return an error, even with rules in conflict.")
+	assert.Equal(t, "the value 1 of the rule 'maxSize' is invalid. It is below the value 2 of the rule 'minSize'", err.Error())
+
+	err = CheckRules(append(rules, Rule{Rule: "minSize", Value: 2}))
+	assert.NotNil(t, err, "CheckRules did not return an error, even with more rules than accepted.")
+	assert.Equal(t, fmt.Sprintf("there are %d rules, above the maximum of %d", MaxRules+1, MaxRules), err.Error())
+}